@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"github.com/go-chi/cors"
 	"log"
 	"net"
 	"net/http"
 	"os"
 
+	"github.com/gauss2302/testcommm/auth/internal/client"
 	"github.com/gauss2302/testcommm/auth/internal/handler"
 	"github.com/gauss2302/testcommm/auth/internal/pkg/jwt"
 	"github.com/gauss2302/testcommm/auth/internal/service"
@@ -40,9 +42,13 @@ func main() {
 
 	// Initialize services
 	jwtMaker := jwt.NewJWTMaker(os.Getenv("JWT_PRIVATE_KEY"))
-	authService := service.NewAuthService(rdb, userClient, jwtMaker)
+	productClient := client.NewProductClient(os.Getenv("PRODUCT_SERVICE_URL"))
+	authService := service.NewAuthService(rdb, userClient, productClient, jwtMaker)
 	authHandler := handler.NewAuthHandler(authService)
 
+	// React to account changes made in the user service
+	go authService.ConsumeUserEvents(context.Background())
+
 	// gRPC server
 	lis, err := net.Listen("tcp", ":50052")
 	if err != nil {
@@ -73,6 +79,41 @@ func main() {
 	r.Post("/register", authHandler.Register)
 	r.Post("/login", authHandler.Login)
 	r.Get("/session", authHandler.GetSession)
+	r.Post("/email/change", authHandler.RequestEmailChange)
+	r.Get("/email/confirm", authHandler.ConfirmEmailChange)
+	r.Get("/email/revert", authHandler.RevertEmailChange)
+	r.Delete("/account", authHandler.DeleteAccount)
+	r.Get("/account/export", authHandler.ExportAccount)
+
+	r.Group(func(r chi.Router) {
+		r.Use(authHandler.RequireUser)
+
+		r.Get("/preferences", authHandler.GetPreferences)
+		r.Put("/preferences", authHandler.UpdatePreferences)
+	})
+
+	r.Route("/orgs", func(r chi.Router) {
+		r.Use(authHandler.RequireUser)
+
+		r.Post("/", authHandler.CreateOrganization)
+		r.Get("/", authHandler.ListOrganizations)
+		r.Get("/{id}/members", authHandler.ListMembers)
+		r.Post("/{id}/members", authHandler.AddMember)
+		r.Delete("/{id}/members/{userID}", authHandler.RemoveMember)
+		r.Post("/{id}/invitations", authHandler.CreateInvitation)
+		r.Get("/{id}/invitations", authHandler.ListInvitations)
+		r.Delete("/{id}/invitations/{invitationID}", authHandler.RevokeInvitation)
+	})
+	r.Get("/invitations", authHandler.GetInvitation)
+	r.Post("/invitations/accept", authHandler.AcceptInvitation)
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(authHandler.RequireAdmin)
+
+		r.Get("/users", authHandler.ListUsers)
+		r.Get("/users/{id}", authHandler.GetUser)
+		r.Post("/users/{id}/status", authHandler.SetUserStatus)
+	})
 
 	log.Printf("starting HTTP server on :8080")
 	log.Fatal(http.ListenAndServe(":8080", r))
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProductClient calls the product service HTTP API on behalf of a user,
+// forwarding the user's access token.
+type ProductClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewProductClient(baseURL string) *ProductClient {
+	return &ProductClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ListUserProducts returns every product owned by the token's user as raw
+// JSON documents.
+func (c *ProductClient) ListUserProducts(ctx context.Context, token string) ([]json.RawMessage, error) {
+	const perPage = 100
+
+	products := []json.RawMessage{}
+	path := fmt.Sprintf("/user/products?per_page=%d&count=false", perPage)
+	for {
+		var resp struct {
+			Products   []json.RawMessage `json:"products"`
+			NextCursor string            `json:"next_cursor"`
+		}
+		if err := c.do(ctx, http.MethodGet, path, token, &resp); err != nil {
+			return nil, err
+		}
+
+		products = append(products, resp.Products...)
+		if resp.NextCursor == "" {
+			return products, nil
+		}
+		path = fmt.Sprintf("/user/products?per_page=%d&count=false&cursor=%s", perPage, url.QueryEscape(resp.NextCursor))
+	}
+}
+
+func (c *ProductClient) do(ctx context.Context, method, path, token string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("product service request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("product service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
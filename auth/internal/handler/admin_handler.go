@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+
+	"github.com/go-chi/chi"
+)
+
+type adminContextKey struct{}
+
+// RequireAdmin only lets requests from users with the admin role through.
+func (h *AuthHandler) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(w, r)
+		if !ok {
+			return
+		}
+
+		admin, err := h.authService.AuthorizeAdmin(r.Context(), token)
+		if err != nil {
+			http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), adminContextKey{}, admin)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (h *AuthHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	resp, err := h.authService.ListUsers(r.Context(), &pb_user.ListUsersRequest{
+		EmailPrefix:   q.Get("email_prefix"),
+		Role:          q.Get("role"),
+		Status:        q.Get("status"),
+		CreatedAfter:  q.Get("created_after"),
+		CreatedBefore: q.Get("created_before"),
+		SortBy:        q.Get("sort"),
+		Descending:    q.Get("order") == "desc",
+		PageSize:      int32(limit),
+		Cursor:        q.Get("cursor"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	users := make([]map[string]interface{}, 0, len(resp.Users))
+	for _, user := range resp.Users {
+		users = append(users, userJSON(user))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"users":       users,
+		"next_cursor": resp.NextCursor,
+	})
+}
+
+func (h *AuthHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.authService.GetUser(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusNotFound))
+		return
+	}
+
+	writeUser(w, user)
+}
+
+type SetStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+func (h *AuthHandler) SetUserStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var req SetStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	admin := r.Context().Value(adminContextKey{}).(*pb_user.User)
+
+	user, err := h.authService.SetUserStatus(r.Context(), admin.Id, id, req.Status, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	writeUser(w, user)
+}
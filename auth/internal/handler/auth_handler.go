@@ -2,10 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gauss2302/testcommm/auth/internal/service"
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type AuthHandler struct {
@@ -36,14 +40,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    tokens.RefreshToken,
-		HttpOnly: true,
-		Secure:   true,
-		Path:     "/refresh-token",
-		MaxAge:   7 * 24 * 60 * 60,
-	})
+	setRefreshCookie(w, tokens.RefreshToken)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -64,14 +61,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    tokens.RefreshToken,
-		HttpOnly: true,
-		Secure:   true,
-		Path:     "/refresh-token",
-		MaxAge:   7 * 24 * 60 * 60,
-	})
+	setRefreshCookie(w, tokens.RefreshToken)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
@@ -81,32 +71,191 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 func (h *AuthHandler) GetSession(w http.ResponseWriter, r *http.Request) {
 	// Получаем токен из Authorization header
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	// Получаем информацию о пользователе
+	user, err := h.authService.GetSession(r.Context(), token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Preferences are a convenience for clients; the session stays usable
+	// without them.
+	prefs, err := h.authService.GetPreferences(r.Context(), user.Id)
+	if err != nil {
+		log.Printf("failed to load preferences for user %d: %v", user.Id, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user":        userJSON(user),
+		"preferences": prefs,
+	})
+}
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+}
+
+func (h *AuthHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	var req EmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.authService.RequestEmailChange(r.Context(), token, req.NewEmail)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusUnauthorized))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"expires_at": resp.ExpiresAt,
+	})
+}
+
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authService.ConfirmEmailChange(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	writeUser(w, user)
+}
+
+func (h *AuthHandler) RevertEmailChange(w http.ResponseWriter, r *http.Request) {
+	user, err := h.authService.RevertEmailChange(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	writeUser(w, user)
+}
+
+func writeUser(w http.ResponseWriter, user *pb_user.User) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": userJSON(user),
+	})
+}
+
+func userJSON(user *pb_user.User) map[string]interface{} {
+	return map[string]interface{}{
+		"id":            user.Id,
+		"email":         user.Email,
+		"role":          user.Role,
+		"status":        user.Status,
+		"status_reason": user.StatusReason,
+		"created_at":    user.CreatedAt,
+	}
+}
+
+func setRefreshCookie(w http.ResponseWriter, refreshToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/refresh-token",
+		MaxAge:   7 * 24 * 60 * 60,
+	})
+}
+
+// bearerToken extracts the token from the Authorization header, writing a 401
+// response when it is missing or malformed.
+func bearerToken(w http.ResponseWriter, r *http.Request) (string, bool) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
 		http.Error(w, "missing authorization header", http.StatusUnauthorized)
-		return
+		return "", false
 	}
 
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
 		http.Error(w, "invalid authorization format", http.StatusUnauthorized)
-		return
+		return "", false
 	}
 
-	token := parts[1]
+	return parts[1], true
+}
 
-	// Получаем информацию о пользователе
-	user, err := h.authService.GetSession(r.Context(), token)
+// httpStatus maps gRPC status codes returned by the user service to HTTP
+// status codes, using fallback for everything else.
+func httpStatus(err error, fallback int) int {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	}
+	return fallback
+}
+
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.authService.DeleteAccount(r.Context(), token)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
 		return
 	}
 
+	// Drop the refresh token cookie along with the sessions
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		HttpOnly: true,
+		Secure:   true,
+		Path:     "/refresh-token",
+		MaxAge:   -1,
+	})
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"user": map[string]interface{}{
-			"id":    user.Id,
-			"email": user.Email,
-		},
+		"user_id":  deleted.UserID,
+		"purge_at": deleted.PurgeAt,
 	})
 }
+
+func (h *AuthHandler) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.authService.ExportAccount(r.Context(), token)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="account-export.json"`)
+	w.Write(data)
+}
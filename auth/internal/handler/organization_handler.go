@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+
+	"github.com/go-chi/chi"
+)
+
+type userIDContextKey struct{}
+
+// RequireUser rejects requests without a valid access token and stores the
+// user ID in the request context.
+func (h *AuthHandler) RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(w, r)
+		if !ok {
+			return
+		}
+
+		userID, err := h.authService.Authenticate(r.Context(), token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDContextKey{}, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func currentUserID(r *http.Request) uint64 {
+	return r.Context().Value(userIDContextKey{}).(uint64)
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func (h *AuthHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.authService.CreateOrganization(r.Context(), currentUserID(r), req.Name)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(organizationJSON(org))
+}
+
+func (h *AuthHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.authService.ListOrganizations(r.Context(), currentUserID(r))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(orgs))
+	for _, org := range orgs {
+		item := organizationJSON(org.Organization)
+		item["role"] = org.Role
+		result = append(result, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"organizations": result,
+	})
+}
+
+func (h *AuthHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := uintParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.authService.ListMembers(r.Context(), currentUserID(r), orgID)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(members))
+	for _, member := range members {
+		result = append(result, memberJSON(member))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"members": result,
+	})
+}
+
+type AddMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required"`
+}
+
+func (h *AuthHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := uintParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req AddMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.authService.AddMember(r.Context(), currentUserID(r), orgID, req.Email, req.Role)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(memberJSON(member))
+}
+
+func (h *AuthHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := uintParam(w, r, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := uintParam(w, r, "userID")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RemoveMember(r.Context(), currentUserID(r), orgID, memberID); err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required"`
+}
+
+func (h *AuthHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := uintParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := h.authService.CreateInvitation(r.Context(), currentUserID(r), orgID, req.Email, req.Role)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitationJSON(invitation))
+}
+
+func (h *AuthHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := uintParam(w, r, "id")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.authService.ListInvitations(r.Context(), currentUserID(r), orgID)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	result := make([]map[string]interface{}, 0, len(invitations))
+	for _, invitation := range invitations {
+		result = append(result, invitationJSON(invitation))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"invitations": result,
+	})
+}
+
+func (h *AuthHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	orgID, ok := uintParam(w, r, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := uintParam(w, r, "invitationID")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeInvitation(r.Context(), currentUserID(r), orgID, invitationID); err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetInvitation previews the invitation behind the link sent by email.
+func (h *AuthHandler) GetInvitation(w http.ResponseWriter, r *http.Request) {
+	invitation, err := h.authService.GetInvitation(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(invitationJSON(invitation))
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+	// Password registers a new account when the request is not authenticated.
+	Password string `json:"password"`
+}
+
+func (h *AuthHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var accessToken string
+	if r.Header.Get("Authorization") != "" {
+		token, ok := bearerToken(w, r)
+		if !ok {
+			return
+		}
+		accessToken = token
+	}
+
+	member, tokens, err := h.authService.AcceptInvitation(r.Context(), req.Token, accessToken, req.Password)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	resp := map[string]interface{}{
+		"member": memberJSON(member),
+	}
+	if tokens != nil {
+		setRefreshCookie(w, tokens.RefreshToken)
+		resp["access_token"] = tokens.AccessToken
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func uintParam(w http.ResponseWriter, r *http.Request, name string) (uint64, bool) {
+	value, err := strconv.ParseUint(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return value, true
+}
+
+func organizationJSON(org *pb_user.Organization) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         org.Id,
+		"name":       org.Name,
+		"created_at": org.CreatedAt,
+	}
+}
+
+func memberJSON(member *pb_user.Member) map[string]interface{} {
+	return map[string]interface{}{
+		"organization_id": member.OrganizationId,
+		"user_id":         member.UserId,
+		"email":           member.Email,
+		"role":            member.Role,
+		"joined_at":       member.JoinedAt,
+	}
+}
+
+func invitationJSON(invitation *pb_user.Invitation) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                invitation.Id,
+		"organization_id":   invitation.OrganizationId,
+		"organization_name": invitation.OrganizationName,
+		"email":             invitation.Email,
+		"role":              invitation.Role,
+		"invited_by":        invitation.InvitedBy,
+		"expires_at":        invitation.ExpiresAt,
+		"created_at":        invitation.CreatedAt,
+	}
+}
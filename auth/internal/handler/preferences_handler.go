@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (h *AuthHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs, err := h.authService.GetPreferences(r.Context(), currentUserID(r))
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	writePreferences(w, prefs)
+}
+
+// UpdatePreferences accepts a JSON object of preference values. An empty
+// string resets a preference to its default.
+func (h *AuthHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var values map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.authService.UpdatePreferences(r.Context(), currentUserID(r), values)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(err, http.StatusInternalServerError))
+		return
+	}
+
+	writePreferences(w, prefs)
+}
+
+func writePreferences(w http.ResponseWriter, prefs map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"preferences": prefs,
+	})
+}
@@ -42,9 +42,11 @@ func (maker *JWTMaker) CreateTokenPair(userID uint64) (*TokenPair, error) {
 }
 
 func (maker *JWTMaker) createToken(userID uint64, duration time.Duration) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(duration).Unix(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(duration).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -60,7 +62,21 @@ func (c *Claims) GetUserID() uint64 {
 	return c.UserID
 }
 
+// Payload holds the verified claims of a token.
+type Payload struct {
+	UserID   uint64
+	IssuedAt time.Time
+}
+
 func (maker *JWTMaker) VerifyToken(tokenString string) (uint64, error) {
+	payload, err := maker.ParseToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return payload.UserID, nil
+}
+
+func (maker *JWTMaker) ParseToken(tokenString string) (*Payload, error) {
 	log.Printf("Verifying token: %s", tokenString)
 
 	// Используем MapClaims вместо структуры Claims
@@ -73,16 +89,22 @@ func (maker *JWTMaker) VerifyToken(tokenString string) (uint64, error) {
 
 	if err != nil {
 		log.Printf("Token parsing error: %v", err)
-		return 0, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		// Получаем user_id из MapClaims
-		if userID, ok := claims["user_id"].(float64); ok {
-			return uint64(userID), nil
+		userID, ok := claims["user_id"].(float64)
+		if !ok {
+			return nil, errors.New("user_id not found in token")
+		}
+		payload := &Payload{UserID: uint64(userID)}
+		// Tokens issued before iat was introduced have no issue time
+		if iat, ok := claims["iat"].(float64); ok {
+			payload.IssuedAt = time.Unix(int64(iat), 0)
 		}
-		return 0, errors.New("user_id not found in token")
+		return payload, nil
 	}
 
-	return 0, errors.New("invalid token")
+	return nil, errors.New("invalid token")
 }
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+type DeletedAccount struct {
+	UserID  uint64
+	PurgeAt string
+}
+
+// DeleteAccount schedules the user's account for purge and revokes every
+// session. Other services react to the user's events: the product service
+// hides the user's products once the account is deleted and removes them
+// when it is purged.
+func (s *AuthService) DeleteAccount(ctx context.Context, token string) (*DeletedAccount, error) {
+	userID, err := s.authenticate(ctx, token)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid token")
+	}
+
+	resp, err := s.userClient.DeleteUser(ctx, &pb_user.DeleteUserRequest{Id: userID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to delete user")
+	}
+
+	if err := s.RevokeSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return &DeletedAccount{
+		UserID:  userID,
+		PurgeAt: resp.PurgeAt,
+	}, nil
+}
+
+type sessionExport struct {
+	HasRefreshToken bool       `json:"has_refresh_token"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+}
+
+type accountExport struct {
+	ExportedAt time.Time         `json:"exported_at"`
+	User       json.RawMessage   `json:"user"`
+	Products   []json.RawMessage `json:"products"`
+	Sessions   sessionExport     `json:"sessions"`
+}
+
+// ExportAccount collects everything the platform stores about the user into
+// a single JSON document.
+func (s *AuthService) ExportAccount(ctx context.Context, token string) ([]byte, error) {
+	userID, err := s.authenticate(ctx, token)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid token")
+	}
+
+	userData, err := s.userClient.ExportUserData(ctx, &pb_user.ExportUserDataRequest{Id: userID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to export user data")
+	}
+
+	products, err := s.productClient.ListUserProducts(ctx, token)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to export products")
+	}
+
+	sessions, err := s.exportSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(accountExport{
+		ExportedAt: time.Now().UTC(),
+		User:       userData.Data,
+		Products:   products,
+		Sessions:   *sessions,
+	}, "", "  ")
+}
+
+func (s *AuthService) exportSessions(ctx context.Context, userID uint64) (*sessionExport, error) {
+	exists, err := s.redis.Exists(ctx, fmt.Sprintf("refresh_token:%d", userID)).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to export sessions")
+	}
+	export := &sessionExport{HasRefreshToken: exists > 0}
+
+	revokedAt, err := s.redis.Get(ctx, fmt.Sprintf("sessions_revoked_at:%d", userID)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, errors.Wrap(err, "failed to export sessions")
+	}
+	if ts, err := strconv.ParseInt(revokedAt, 10, 64); err == nil {
+		t := time.Unix(ts, 0).UTC()
+		export.RevokedAt = &t
+	}
+
+	return export, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	roleAdmin    = "admin"
+	statusActive = "active"
+)
+
+// AuthorizeAdmin resolves the token to a user and checks that the user holds the
+// admin role.
+func (s *AuthService) AuthorizeAdmin(ctx context.Context, token string) (*pb_user.User, error) {
+	userID, err := s.authenticate(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	user, err := s.userClient.GetUserByID(ctx, &pb_user.GetUserByIDRequest{Id: userID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get user")
+	}
+	if user.Role != roleAdmin {
+		return nil, status.Error(codes.PermissionDenied, "admin role required")
+	}
+
+	return user, nil
+}
+
+func (s *AuthService) ListUsers(ctx context.Context, req *pb_user.ListUsersRequest) (*pb_user.ListUsersResponse, error) {
+	resp, err := s.userClient.ListUsers(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list users")
+	}
+	return resp, nil
+}
+
+func (s *AuthService) GetUser(ctx context.Context, id uint64) (*pb_user.User, error) {
+	user, err := s.userClient.GetUserByID(ctx, &pb_user.GetUserByIDRequest{Id: id})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get user")
+	}
+	return user, nil
+}
+
+// SetUserStatus changes the account status and immediately blocks or
+// unblocks the user's tokens.
+func (s *AuthService) SetUserStatus(ctx context.Context, actorID, id uint64, status, reason string) (*pb_user.User, error) {
+	user, err := s.userClient.SetUserStatus(ctx, &pb_user.SetUserStatusRequest{
+		Id:      id,
+		Status:  status,
+		Reason:  reason,
+		ActorId: actorID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to set user status")
+	}
+
+	if err := s.SetUserBlocked(ctx, user.Id, user.Status); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/gauss2302/testcommm/auth/internal/client"
 	"github.com/gauss2302/testcommm/auth/internal/pkg/jwt"
 	pb_auth "github.com/gauss2302/testcommm/auth/proto/auth"
 	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
@@ -16,31 +17,39 @@ import (
 
 type AuthService struct {
 	pb_auth.UnimplementedAuthServiceServer
-	redis      *redis.Client
-	userClient pb_user.UserServiceClient
-	jwtMaker   *jwt.JWTMaker
+	redis         *redis.Client
+	userClient    pb_user.UserServiceClient
+	productClient *client.ProductClient
+	jwtMaker      *jwt.JWTMaker
 }
 
-func NewAuthService(redis *redis.Client, userClient pb_user.UserServiceClient, jwtMaker *jwt.JWTMaker) *AuthService {
+func NewAuthService(redis *redis.Client, userClient pb_user.UserServiceClient, productClient *client.ProductClient, jwtMaker *jwt.JWTMaker) *AuthService {
 	return &AuthService{
-		redis:      redis,
-		userClient: userClient,
-		jwtMaker:   jwtMaker,
+		redis:         redis,
+		userClient:    userClient,
+		productClient: productClient,
+		jwtMaker:      jwtMaker,
 	}
 }
 
 func (s *AuthService) Register(ctx context.Context, email, password string) (*jwt.TokenPair, error) {
+	_, tokens, err := s.register(ctx, email, password)
+	return tokens, err
+}
+
+// register creates the account and signs the new user in.
+func (s *AuthService) register(ctx context.Context, email, password string) (*pb_user.User, *jwt.TokenPair, error) {
 	user, err := s.userClient.CreateUser(ctx, &pb_user.CreateUserRequest{
 		Email:    email,
 		Password: password,
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create user")
+		return nil, nil, errors.Wrap(err, "failed to create user")
 	}
 
 	tokens, err := s.jwtMaker.CreateTokenPair(user.Id)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create tokens")
+		return nil, nil, errors.Wrap(err, "failed to create tokens")
 	}
 
 	err = s.redis.Set(ctx,
@@ -49,10 +58,10 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*jw
 		time.Hour*24*7,
 	).Err()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to save refresh token")
+		return nil, nil, errors.Wrap(err, "failed to save refresh token")
 	}
 
-	return tokens, nil
+	return user, tokens, nil
 }
 
 func (s *AuthService) Login(ctx context.Context, email, password string) (*jwt.TokenPair, error) {
@@ -84,7 +93,7 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*jwt.T
 func (s *AuthService) VerifyToken(ctx context.Context, req *pb_auth.VerifyTokenRequest) (*pb_auth.VerifyTokenResponse, error) {
 	log.Printf("Received verification request for token: %s", req.Token)
 
-	userID, err := s.jwtMaker.VerifyToken(req.Token)
+	userID, err := s.authenticate(ctx, req.Token)
 	if err != nil {
 		log.Printf("Failed to verify token: %v", err)
 		return nil, err
@@ -98,7 +107,7 @@ func (s *AuthService) VerifyToken(ctx context.Context, req *pb_auth.VerifyTokenR
 
 func (s *AuthService) GetSession(ctx context.Context, token string) (*pb_user.User, error) {
 	// Верифицируем токен
-	userID, err := s.jwtMaker.VerifyToken(token)
+	userID, err := s.authenticate(ctx, token)
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid token")
 	}
@@ -113,3 +122,54 @@ func (s *AuthService) GetSession(ctx context.Context, token string) (*pb_user.Us
 
 	return user, nil
 }
+
+func (s *AuthService) RequestEmailChange(ctx context.Context, token, newEmail string) (*pb_user.RequestEmailChangeResponse, error) {
+	userID, err := s.authenticate(ctx, token)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid token")
+	}
+
+	resp, err := s.userClient.RequestEmailChange(ctx, &pb_user.RequestEmailChangeRequest{
+		UserId:   userID,
+		NewEmail: newEmail,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to request email change")
+	}
+
+	return resp, nil
+}
+
+// ConfirmEmailChange applies a pending email change and signs the user out
+// everywhere.
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) (*pb_user.User, error) {
+	user, err := s.userClient.ConfirmEmailChange(ctx, &pb_user.ConfirmEmailChangeRequest{
+		Token: token,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to confirm email change")
+	}
+
+	if err := s.RevokeSessions(ctx, user.Id); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RevertEmailChange restores the previous email address and signs the user
+// out everywhere, since the change may have been made by someone else.
+func (s *AuthService) RevertEmailChange(ctx context.Context, token string) (*pb_user.User, error) {
+	user, err := s.userClient.RevertEmailChange(ctx, &pb_user.RevertEmailChangeRequest{
+		Token: token,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to revert email change")
+	}
+
+	if err := s.RevokeSessions(ctx, user.Id); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
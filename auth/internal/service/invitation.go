@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/gauss2302/testcommm/auth/internal/pkg/jwt"
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *AuthService) CreateInvitation(ctx context.Context, userID, orgID uint64, email, role string) (*pb_user.Invitation, error) {
+	invitation, err := s.userClient.CreateInvitation(ctx, &pb_user.CreateInvitationRequest{
+		OrganizationId: orgID,
+		ActorId:        userID,
+		Email:          email,
+		Role:           role,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create invitation")
+	}
+	return invitation, nil
+}
+
+func (s *AuthService) ListInvitations(ctx context.Context, userID, orgID uint64) ([]*pb_user.Invitation, error) {
+	resp, err := s.userClient.ListInvitations(ctx, &pb_user.ListInvitationsRequest{
+		OrganizationId: orgID,
+		ActorId:        userID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list invitations")
+	}
+	return resp.Invitations, nil
+}
+
+func (s *AuthService) RevokeInvitation(ctx context.Context, userID, orgID, invitationID uint64) error {
+	_, err := s.userClient.RevokeInvitation(ctx, &pb_user.RevokeInvitationRequest{
+		OrganizationId: orgID,
+		ActorId:        userID,
+		InvitationId:   invitationID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to revoke invitation")
+	}
+	return nil
+}
+
+func (s *AuthService) GetInvitation(ctx context.Context, token string) (*pb_user.Invitation, error) {
+	invitation, err := s.userClient.GetInvitation(ctx, &pb_user.GetInvitationRequest{Token: token})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get invitation")
+	}
+	return invitation, nil
+}
+
+// AcceptInvitation joins the organization as the signed-in user identified by
+// accessToken. Without an access token a new account is registered for the
+// invited email with the given password, and its tokens are returned.
+func (s *AuthService) AcceptInvitation(ctx context.Context, invitationToken, accessToken, password string) (*pb_user.Member, *jwt.TokenPair, error) {
+	var userID uint64
+	var tokens *jwt.TokenPair
+
+	if accessToken != "" {
+		id, err := s.Authenticate(ctx, accessToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		userID = id
+	} else {
+		if password == "" {
+			return nil, nil, status.Error(codes.InvalidArgument, "password is required to create an account")
+		}
+
+		invitation, err := s.GetInvitation(ctx, invitationToken)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		user, pair, err := s.register(ctx, invitation.Email, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		userID, tokens = user.Id, pair
+	}
+
+	member, err := s.userClient.AcceptInvitation(ctx, &pb_user.AcceptInvitationRequest{
+		Token:  invitationToken,
+		UserId: userID,
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to accept invitation")
+	}
+
+	return member, tokens, nil
+}
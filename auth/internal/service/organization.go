@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+
+	"github.com/pkg/errors"
+)
+
+func (s *AuthService) CreateOrganization(ctx context.Context, userID uint64, name string) (*pb_user.Organization, error) {
+	org, err := s.userClient.CreateOrganization(ctx, &pb_user.CreateOrganizationRequest{
+		Name:    name,
+		OwnerId: userID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create organization")
+	}
+	return org, nil
+}
+
+func (s *AuthService) ListOrganizations(ctx context.Context, userID uint64) ([]*pb_user.UserOrganization, error) {
+	resp, err := s.userClient.ListUserOrganizations(ctx, &pb_user.ListUserOrganizationsRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list organizations")
+	}
+	return resp.Organizations, nil
+}
+
+func (s *AuthService) ListMembers(ctx context.Context, userID, orgID uint64) ([]*pb_user.Member, error) {
+	resp, err := s.userClient.ListMembers(ctx, &pb_user.ListMembersRequest{
+		OrganizationId: orgID,
+		ActorId:        userID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list members")
+	}
+	return resp.Members, nil
+}
+
+func (s *AuthService) AddMember(ctx context.Context, userID, orgID uint64, email, role string) (*pb_user.Member, error) {
+	member, err := s.userClient.AddMember(ctx, &pb_user.AddMemberRequest{
+		OrganizationId: orgID,
+		ActorId:        userID,
+		Email:          email,
+		Role:           role,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to add member")
+	}
+	return member, nil
+}
+
+func (s *AuthService) RemoveMember(ctx context.Context, userID, orgID, memberID uint64) error {
+	_, err := s.userClient.RemoveMember(ctx, &pb_user.RemoveMemberRequest{
+		OrganizationId: orgID,
+		ActorId:        userID,
+		UserId:         memberID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to remove member")
+	}
+	return nil
+}
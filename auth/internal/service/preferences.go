@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+// preferencesTTL bounds how stale cached preferences can get when they are
+// changed without going through this service.
+const preferencesTTL = 10 * time.Minute
+
+func preferencesKey(userID uint64) string {
+	return fmt.Sprintf("preferences:%d", userID)
+}
+
+// GetPreferences returns the user's preferences, served from Redis when cached.
+func (s *AuthService) GetPreferences(ctx context.Context, userID uint64) (map[string]string, error) {
+	cached, err := s.redis.Get(ctx, preferencesKey(userID)).Bytes()
+	if err == nil {
+		var values map[string]string
+		if err := json.Unmarshal(cached, &values); err == nil {
+			return values, nil
+		}
+	} else if err != redis.Nil {
+		log.Printf("failed to read cached preferences for user %d: %v", userID, err)
+	}
+
+	prefs, err := s.userClient.GetPreferences(ctx, &pb_user.GetPreferencesRequest{UserId: userID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get preferences")
+	}
+
+	s.cachePreferences(ctx, userID, prefs.Values)
+	return prefs.Values, nil
+}
+
+// UpdatePreferences stores the given values and refreshes the cache.
+func (s *AuthService) UpdatePreferences(ctx context.Context, userID uint64, values map[string]string) (map[string]string, error) {
+	prefs, err := s.userClient.UpdatePreferences(ctx, &pb_user.UpdatePreferencesRequest{
+		UserId: userID,
+		Values: values,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update preferences")
+	}
+
+	s.cachePreferences(ctx, userID, prefs.Values)
+	return prefs.Values, nil
+}
+
+func (s *AuthService) cachePreferences(ctx context.Context, userID uint64, values map[string]string) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return
+	}
+	if err := s.redis.Set(ctx, preferencesKey(userID), data, preferencesTTL).Err(); err != nil {
+		log.Printf("failed to cache preferences for user %d: %v", userID, err)
+	}
+}
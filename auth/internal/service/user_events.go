@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	pb_user "github.com/gauss2302/testcommm/auth/proto/user"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const userEventsCursorKey = "user_events:cursor"
+
+// ConsumeUserEvents follows the user service event stream and revokes or
+// restores access when accounts change status or are deleted. The cursor is
+// kept in Redis so the consumer resumes where it stopped after a restart.
+func (s *AuthService) ConsumeUserEvents(ctx context.Context) {
+	for {
+		err := s.consumeUserEvents(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("User event stream interrupted: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (s *AuthService) consumeUserEvents(ctx context.Context) error {
+	cursor, err := s.redis.Get(ctx, userEventsCursorKey).Result()
+	if err != nil && err != redis.Nil {
+		return errors.Wrap(err, "failed to load cursor")
+	}
+
+	stream, err := s.userClient.WatchUserEvents(ctx, &pb_user.WatchUserEventsRequest{
+		Cursor:        cursor,
+		StartAtLatest: cursor == "",
+		Types:         []string{"user.status_changed", "user.deleted", "user.purged"},
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return errors.New("stream closed")
+		}
+		if status.Code(err) == codes.OutOfRange {
+			// The events after our cursor were pruned; skip to the latest
+			// rather than retry forever
+			log.Printf("Missed pruned user events, resuming from the latest: %v", err)
+			if err := s.redis.Del(ctx, userEventsCursorKey).Err(); err != nil {
+				return errors.Wrap(err, "failed to reset cursor")
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.handleUserEvent(ctx, event); err != nil {
+			return errors.Wrapf(err, "failed to handle event %s", event.Cursor)
+		}
+		if err := s.redis.Set(ctx, userEventsCursorKey, event.Cursor, 0).Err(); err != nil {
+			return errors.Wrap(err, "failed to save cursor")
+		}
+	}
+}
+
+func (s *AuthService) handleUserEvent(ctx context.Context, event *pb_user.UserEvent) error {
+	switch event.Type {
+	case "user.status_changed":
+		return s.SetUserBlocked(ctx, event.UserId, event.User.Status)
+	case "user.deleted":
+		return s.SetUserBlocked(ctx, event.UserId, "deleted")
+	case "user.purged":
+		// IDs are never reused, so nothing is left to protect
+		return s.redis.Del(ctx,
+			fmt.Sprintf("user_blocked:%d", event.UserId),
+			fmt.Sprintf("sessions_revoked_at:%d", event.UserId),
+			fmt.Sprintf("refresh_token:%d", event.UserId),
+			preferencesKey(event.UserId),
+		).Err()
+	}
+	return nil
+}
@@ -2,9 +2,14 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"github.com/gauss2302/testcommm/product/internal/blob"
 	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/exchange"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"time"
@@ -22,8 +27,14 @@ import (
 	"github.com/gauss2302/testcommm/product/internal/repository"
 	"github.com/gauss2302/testcommm/product/internal/service"
 	pb "github.com/gauss2302/testcommm/product/proto/auth"
+	pb_product "github.com/gauss2302/testcommm/product/proto/product"
+	pb_user "github.com/gauss2302/testcommm/product/proto/user"
 )
 
+// schedulerLockKey is the Postgres advisory lock held by the replica that
+// runs scheduled actions.
+const schedulerLockKey = 0x70726f64
+
 func main() {
 	// Database connection with retry
 	var db *gorm.DB
@@ -32,7 +43,8 @@ func main() {
 	maxRetries := 5
 	for i := 0; i < maxRetries; i++ {
 		db, err = gorm.Open(postgres.Open(os.Getenv("DATABASE_URL")), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Error),
+			Logger:         logger.Default.LogMode(logger.Error),
+			TranslateError: true,
 		})
 		if err == nil {
 			break
@@ -47,7 +59,28 @@ func main() {
 	log.Printf("Successfully connected to database")
 
 	// Auto migrate
-	if err := db.AutoMigrate(&entity.Product{}); err != nil {
+	if err := repository.MigratePrices(db); err != nil {
+		log.Fatalf("Failed to migrate prices: %v", err)
+	}
+	if err := repository.MigrateProductStatus(db); err != nil {
+		log.Fatalf("Failed to migrate product status: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&entity.Product{},
+		&entity.EventCursor{},
+		&entity.Category{},
+		&entity.ProductCategory{},
+		&entity.Tag{},
+		&entity.ProductTag{},
+		&entity.Variant{},
+		&entity.InventoryItem{},
+		&entity.InventoryEntry{},
+		&entity.Reservation{},
+		&entity.ProductImage{},
+		&entity.ScheduledAction{},
+		&entity.PriceChange{},
+		&entity.Promotion{},
+	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
@@ -75,12 +108,63 @@ func main() {
 
 	authClient := pb.NewAuthServiceClient(authConn)
 
+	// Connect to user service, used to embed product owners
+	userConn, err := grpc.Dial(
+		os.Getenv("USER_SERVICE_ADDR"),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		log.Fatalf("Failed to connect to user service: %v", err)
+	}
+	defer userConn.Close()
+
+	userClient := pb_user.NewUserServiceClient(userConn)
+
+	blobs, mediaDir := blobStore()
+
 	// Initialize components
 	productRepo := repository.NewProductRepository(db)
-	productService := service.NewProductService(productRepo)
+	categoryRepo := repository.NewCategoryRepository(db)
+	variantRepo := repository.NewVariantRepository(db)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	imageRepo := repository.NewImageRepository(db)
+	priceRepo := repository.NewPriceRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	cursorRepo := repository.NewEventCursorRepository(db)
+	productService := service.NewProductService(productRepo, categoryRepo, variantRepo, inventoryRepo, imageRepo, priceRepo, scheduleRepo, cursorRepo, userClient, exchangeConverter(), blobs, os.Getenv("PRODUCT_REVIEW") == "required", cursorSecret())
 	productHandler := handler.NewProductHandler(productService)
 	auth := authMiddleware.NewAuthMiddleware(authClient)
 
+	// Hide listings of sellers that are suspended, banned or deleted
+	go productService.ConsumeUserEvents(context.Background())
+
+	// Give back the stock of reservations that were never committed
+	go productService.ExpireReservations(context.Background(), time.Minute)
+
+	// Run scheduled publishes and price changes on one replica at a time
+	go productService.RunSchedules(context.Background(), repository.NewLeaderLock(db, schedulerLockKey), 30*time.Second)
+
+	// Delete products for good once they have been in the trash long enough
+	go productService.PurgeTrash(context.Background(), trashRetention(), time.Hour)
+
+	// Serve the gRPC API alongside HTTP
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "50053"
+	}
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen on gRPC port: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryInterceptor))
+	pb_product.RegisterProductServiceServer(grpcServer, handler.NewProductGRPCHandler(productService))
+	go func() {
+		log.Printf("Starting product gRPC server on :%s", grpcPort)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve gRPC: %v", err)
+		}
+	}()
+
 	// Setup router
 	r := chi.NewRouter()
 
@@ -92,6 +176,11 @@ func main() {
 	// Then define routes
 	r.Handle("/metrics", promhttp.Handler())
 
+	// Images kept on the local filesystem are public, but only by their keys
+	if mediaDir != "" {
+		r.Handle("/media/*", http.StripPrefix("/media/", blob.FileServer(mediaDir)))
+	}
+
 	// Protected routes
 	r.Group(func(r chi.Router) {
 		r.Use(auth.Authenticate)
@@ -102,6 +191,51 @@ func main() {
 		r.Put("/products/{id}", productHandler.Update)
 		r.Delete("/products/{id}", productHandler.Delete)
 		r.Get("/user/products", productHandler.ListUserProducts)
+		r.Get("/user/products/trash", productHandler.ListTrash)
+		r.Post("/products/{id}/restore", productHandler.Restore)
+		r.Delete("/products/{id}/permanent", productHandler.Purge)
+		r.Put("/products/{id}/categories", productHandler.SetProductCategories)
+		r.Post("/products/{id}/publish", productHandler.Publish)
+		r.Post("/products/{id}/unpublish", productHandler.Unpublish)
+		r.Post("/products/{id}/archive", productHandler.Archive)
+		r.Get("/products/{id}/schedules", productHandler.ListSchedules)
+		r.Post("/products/{id}/schedules", productHandler.ScheduleAction)
+		r.Delete("/products/{id}/schedules/{scheduleID}", productHandler.CancelSchedule)
+		r.Get("/products/{id}/price-history", productHandler.ListPriceHistory)
+		r.Get("/products/{id}/promotions", productHandler.ListPromotions)
+		r.Post("/products/{id}/promotions", productHandler.CreatePromotion)
+		r.Delete("/products/{id}/promotions/{promotionID}", productHandler.DeletePromotion)
+		r.Get("/products/{id}/variants", productHandler.ListVariants)
+		r.Post("/products/{id}/variants", productHandler.CreateVariant)
+		r.Get("/products/{id}/variants/{variantID}", productHandler.GetVariant)
+		r.Put("/products/{id}/variants/{variantID}", productHandler.UpdateVariant)
+		r.Delete("/products/{id}/variants/{variantID}", productHandler.DeleteVariant)
+		r.Get("/products/{id}/inventory", productHandler.GetInventory)
+		r.Post("/products/{id}/inventory/adjustments", productHandler.AdjustInventory)
+		r.Get("/products/{id}/inventory/ledger", productHandler.ListInventoryLedger)
+
+		// Reservations hold stock until they are committed or released
+		r.Post("/reservations", productHandler.ReserveStock)
+		r.Get("/reservations/{id}", productHandler.GetReservation)
+		r.Post("/reservations/{id}/commit", productHandler.CommitReservation)
+		r.Post("/reservations/{id}/release", productHandler.ReleaseReservation)
+
+		r.Get("/products/{id}/images", productHandler.ListImages)
+		r.Post("/products/{id}/images", productHandler.UploadImage)
+		r.Put("/products/{id}/images/order", productHandler.ReorderImages)
+		r.Put("/products/{id}/images/{imageID}/primary", productHandler.SetPrimaryImage)
+		r.Delete("/products/{id}/images/{imageID}", productHandler.DeleteImage)
+
+		// Admins moderate products when PRODUCT_REVIEW is "required"
+		r.Get("/moderation/products", productHandler.ListPending)
+		r.Post("/moderation/products/{id}/approve", productHandler.Approve)
+		r.Post("/moderation/products/{id}/reject", productHandler.Reject)
+
+		// Categories are readable by everyone and managed by admins
+		r.Get("/categories", productHandler.ListCategories)
+		r.Post("/categories", productHandler.CreateCategory)
+		r.Put("/categories/{id}", productHandler.UpdateCategory)
+		r.Delete("/categories/{id}", productHandler.DeleteCategory)
 	})
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -113,3 +247,101 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// cursorSecret returns the key signing listing cursors. Replicas must share
+// it, otherwise cursors only work against the replica that issued them.
+func cursorSecret() []byte {
+	if secret := os.Getenv("CURSOR_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Printf("CURSOR_SECRET is not set, using a random key")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Failed to generate cursor secret: %v", err)
+	}
+	return secret
+}
+
+// trashRetention reads how long deleted products can be restored from
+// TRASH_RETENTION, a Go duration such as "720h".
+func trashRetention() time.Duration {
+	value := os.Getenv("TRASH_RETENTION")
+	if value == "" {
+		return service.DefaultTrashRetention
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention <= 0 {
+		log.Fatalf("Invalid TRASH_RETENTION %q", value)
+	}
+	return retention
+}
+
+// blobStore builds the store for uploaded images from BLOB_STORE. "local",
+// the default, keeps files in MEDIA_DIR and serves them under /media, which
+// clients reach at MEDIA_BASE_URL. "s3" uploads them to S3_BUCKET on the
+// S3-compatible S3_ENDPOINT, served from S3_PUBLIC_URL. The returned
+// directory is empty unless files are served locally.
+func blobStore() (blob.Store, string) {
+	switch os.Getenv("BLOB_STORE") {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "media"
+		}
+		baseURL := os.Getenv("MEDIA_BASE_URL")
+		if baseURL == "" {
+			baseURL = "/media"
+		}
+		store, err := blob.NewLocalStore(dir, baseURL)
+		if err != nil {
+			log.Fatalf("Failed to create media directory: %v", err)
+		}
+		return store, dir
+	case "s3":
+		store, err := blob.NewS3Store(
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_REGION"),
+			os.Getenv("S3_BUCKET"),
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+			os.Getenv("S3_PUBLIC_URL"),
+		)
+		if err != nil {
+			log.Fatalf("Invalid S3 configuration: %v", err)
+		}
+		return store, ""
+	}
+	log.Fatalf("Unknown BLOB_STORE %q", os.Getenv("BLOB_STORE"))
+	return nil, ""
+}
+
+// exchangeConverter builds the currency converter from RATES_PROVIDER
+// ("file", the default, reading RATES_FILE, or "http" fetching RATES_URL) with
+// rates cached for RATES_TTL.
+func exchangeConverter() *exchange.Converter {
+	ttl := time.Hour
+	if v := os.Getenv("RATES_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid RATES_TTL: %v", err)
+		}
+		ttl = d
+	}
+
+	var provider exchange.Provider
+	switch os.Getenv("RATES_PROVIDER") {
+	case "", "file":
+		path := os.Getenv("RATES_FILE")
+		if path == "" {
+			path = "rates.json"
+		}
+		provider = exchange.NewFileProvider(path)
+	case "http":
+		provider = exchange.NewHTTPProvider(os.Getenv("RATES_URL"))
+	default:
+		log.Fatalf("Unknown RATES_PROVIDER %q", os.Getenv("RATES_PROVIDER"))
+	}
+
+	return exchange.NewConverter(provider, ttl)
+}
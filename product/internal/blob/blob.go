@@ -0,0 +1,34 @@
+// Package blob stores uploaded files, such as product images, and tells
+// clients where to fetch them.
+package blob
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store is a flat namespace of blobs addressed by slash-separated keys.
+type Store interface {
+	// Put stores data under key, replacing any previous blob.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes the blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the address clients download the blob from.
+	URL(key string) string
+}
+
+// validKey rejects keys that could escape the store's namespace.
+func validKey(key string) bool {
+	if key == "" || key[0] == '/' {
+		return false
+	}
+	for _, part := range strings.Split(strings.ReplaceAll(key, `\`, "/"), "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
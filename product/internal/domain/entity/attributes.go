@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Attributes are structured properties of a product, such as
+// {"voltage": 230, "material": "cotton"}. Values are strings, numbers or
+// booleans.
+type Attributes map[string]interface{}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+func (a *Attributes) Scan(value interface{}) error {
+	data, err := jsonBytes(value)
+	if err != nil || data == nil {
+		*a = Attributes{}
+		return err
+	}
+	return json.Unmarshal(data, a)
+}
+
+// Attribute types of a schema.
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeInteger = "integer"
+	AttributeBoolean = "boolean"
+	AttributeEnum    = "enum"
+)
+
+// AttributeDef declares an attribute products of a category may have.
+type AttributeDef struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Values lists the choices of an enum attribute.
+	Values   []string `json:"values,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	// Unit documents the unit of a numeric attribute, e.g. "V".
+	Unit string `json:"unit,omitempty"`
+}
+
+// AttributeSchema declares the attributes of a category's products.
+// Subcategories inherit the attributes of their ancestors.
+type AttributeSchema []AttributeDef
+
+func (s AttributeSchema) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(s)
+	return string(data), err
+}
+
+func (s *AttributeSchema) Scan(value interface{}) error {
+	data, err := jsonBytes(value)
+	if err != nil || data == nil {
+		*s = AttributeSchema{}
+		return err
+	}
+	return json.Unmarshal(data, s)
+}
+
+func jsonBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("cannot scan %T as JSON", value)
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// Category is a node of the product taxonomy.
+type Category struct {
+	ID       uint   `gorm:"primarykey" json:"id"`
+	ParentID *uint  `gorm:"index" json:"parent_id"`
+	Name     string `gorm:"not null" json:"name"`
+	Slug     string `gorm:"not null;uniqueIndex" json:"slug"`
+	// Path lists the IDs from the root down to this category, e.g. "/1/4/9/",
+	// so descendants can be matched with a prefix.
+	Path string `gorm:"not null;index" json:"-"`
+	// AttributeSchema declares the attributes of products in this category
+	// and its subcategories.
+	AttributeSchema AttributeSchema `gorm:"type:jsonb;not null;default:'[]'" json:"attribute_schema"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+
+	Parent *Category `gorm:"constraint:OnDelete:RESTRICT" json:"-"`
+}
+
+// ProductCategory assigns a product to a category.
+type ProductCategory struct {
+	ProductID  uint `gorm:"primarykey"`
+	CategoryID uint `gorm:"primarykey;index"`
+
+	Product  Product  `gorm:"constraint:OnDelete:CASCADE"`
+	Category Category `gorm:"constraint:OnDelete:CASCADE"`
+}
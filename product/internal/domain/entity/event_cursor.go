@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// EventCursor remembers how far a consumer has read an external event stream.
+type EventCursor struct {
+	Name      string `gorm:"primarykey"`
+	Cursor    string `gorm:"not null"`
+	UpdatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// ProductImage is an uploaded image of a product. The original is stored
+// under Key, its thumbnails next to it in ThumbnailFormat.
+type ProductImage struct {
+	ID uint `gorm:"primarykey" json:"id"`
+	// At most one image per product is primary.
+	ProductID       uint      `gorm:"not null;index;uniqueIndex:idx_product_primary_image,where:is_primary" json:"product_id"`
+	Key             string    `gorm:"not null" json:"-"`
+	ContentType     string    `gorm:"not null" json:"content_type"`
+	Size            int64     `gorm:"not null" json:"size"`
+	Width           int       `gorm:"not null" json:"width"`
+	Height          int       `gorm:"not null" json:"height"`
+	ThumbnailFormat string    `gorm:"not null" json:"-"`
+	Position        int       `gorm:"not null;default:0" json:"position"`
+	IsPrimary       bool      `gorm:"not null;default:false" json:"is_primary"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// URLs of the original and its thumbnails by size name, filled in from
+	// the blob store.
+	URL        string            `gorm:"-" json:"url"`
+	Thumbnails map[string]string `gorm:"-" json:"thumbnails"`
+
+	Product Product `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
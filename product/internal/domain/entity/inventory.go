@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InventoryItem holds the stock of a product, or of one of its variants.
+// Products sold without variants are tracked with VariantID 0.
+type InventoryItem struct {
+	ID        uint `gorm:"primarykey" json:"-"`
+	ProductID uint `gorm:"not null;uniqueIndex:idx_inventory_item" json:"product_id"`
+	VariantID uint `gorm:"not null;default:0;uniqueIndex:idx_inventory_item" json:"variant_id"`
+	// OnHand is the physical stock; Reserved of it is held for pending
+	// reservations.
+	OnHand    int       `gorm:"not null;default:0;check:on_hand >= 0" json:"on_hand"`
+	Reserved  int       `gorm:"not null;default:0;check:reserved >= 0" json:"reserved"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// ArchivedAt is set when the variant is deleted; the item is kept so
+	// that its ledger and reservations stay on record.
+	ArchivedAt *time.Time `json:"-"`
+
+	// Available is the stock that can still be reserved.
+	Available int `gorm:"-" json:"available"`
+
+	Product Product `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (i *InventoryItem) AfterFind(tx *gorm.DB) error {
+	i.Available = i.OnHand - i.Reserved
+	return nil
+}
+
+// Reasons recorded in the inventory ledger.
+const (
+	InventoryAdjustment  = "adjustment"
+	InventoryReservation = "reservation"
+	InventoryCommit      = "commit"
+	InventoryRelease     = "release"
+	InventoryExpiry      = "expiry"
+)
+
+// InventoryEntry is a ledger record of a change to an inventory item.
+type InventoryEntry struct {
+	ID        uint `gorm:"primarykey" json:"id"`
+	ItemID    uint `gorm:"not null;index" json:"-"`
+	ProductID uint `gorm:"not null;index" json:"product_id"`
+	VariantID uint `gorm:"not null;default:0" json:"variant_id"`
+	// OnHandDelta and ReservedDelta are the changes to the item's counters.
+	OnHandDelta   int       `gorm:"not null;default:0" json:"on_hand_delta"`
+	ReservedDelta int       `gorm:"not null;default:0" json:"reserved_delta"`
+	Reason        string    `gorm:"not null" json:"reason"`
+	ReservationID *uint     `json:"reservation_id,omitempty"`
+	ActorID       uint64    `gorm:"not null;default:0" json:"actor_id"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	Item InventoryItem `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Reservation statuses. Only pending reservations hold stock.
+const (
+	ReservationPending   = "pending"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// Reservation holds stock for a buyer until it is committed, released or
+// expires.
+type Reservation struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	ItemID    uint      `gorm:"not null;index" json:"-"`
+	ProductID uint      `gorm:"not null" json:"product_id"`
+	VariantID uint      `gorm:"not null;default:0" json:"variant_id"`
+	Quantity  int       `gorm:"not null;check:quantity > 0" json:"quantity"`
+	Status    string    `gorm:"not null;default:pending;index:idx_reservation_expiry,priority:1" json:"status"`
+	UserID    uint64    `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index:idx_reservation_expiry,priority:2" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Item InventoryItem `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Expired reports whether the reservation's TTL has passed at now.
+func (r *Reservation) Expired(now time.Time) bool {
+	return !r.ExpiresAt.After(now)
+}
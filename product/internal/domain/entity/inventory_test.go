@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReservationExpired(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expiresAt time.Time
+		want      bool
+	}{
+		{now.Add(time.Second), false},
+		{now, true},
+		{now.Add(-time.Second), true},
+	}
+
+	for _, tt := range tests {
+		r := &Reservation{ExpiresAt: tt.expiresAt}
+		if got := r.Expired(now); got != tt.want {
+			t.Errorf("Expired with expires_at %v = %v, want %v", tt.expiresAt, got, tt.want)
+		}
+	}
+}
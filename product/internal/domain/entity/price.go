@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"gorm.io/gorm"
+)
+
+// Reasons recorded in the price history.
+const (
+	PriceCreated   = "created"
+	PriceUpdated   = "updated"
+	PriceScheduled = "scheduled"
+	// Promotions change the price buyers pay while they run.
+	PricePromotionStarted   = "promotion_started"
+	PricePromotionEnded     = "promotion_ended"
+	PricePromotionCancelled = "promotion_cancelled"
+)
+
+// PriceChange records a change to the price of a product, or to the price
+// buyers pay when a promotion starts or ends. The first entry of a product
+// has no old price.
+type PriceChange struct {
+	ID            uint    `gorm:"primarykey" json:"id"`
+	ProductID     uint    `gorm:"not null;index:idx_price_history,priority:1" json:"product_id"`
+	OldPriceMinor *int64  `json:"old_price_minor,omitempty"`
+	OldCurrency   *string `gorm:"size:3" json:"old_currency,omitempty"`
+	PriceMinor    int64   `gorm:"not null" json:"price_minor"`
+	Currency      string  `gorm:"size:3;not null" json:"currency"`
+	Reason        string  `gorm:"size:20;not null" json:"reason"`
+	// PromotionID is the promotion that started or ended.
+	PromotionID *uint `gorm:"index" json:"promotion_id,omitempty"`
+	// ActorID is the user who changed the price, or who scheduled the change.
+	ActorID   uint64    `gorm:"not null" json:"actor_id"`
+	CreatedAt time.Time `gorm:"index:idx_price_history,priority:2" json:"created_at"`
+
+	Product Product `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Promotion is a sale price of a product for a period of time. It applies
+// while it runs if it is in the product's currency and below its price.
+// Cancelled promotions are soft-deleted so the price history can refer to
+// them.
+type Promotion struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	ProductID  uint      `gorm:"not null;index:idx_promotion_period,priority:1" json:"product_id"`
+	PriceMinor int64     `gorm:"not null" json:"price_minor"`
+	Currency   string    `gorm:"size:3;not null" json:"currency"`
+	StartsAt   time.Time `gorm:"not null;index:idx_promotion_period,priority:2" json:"starts_at"`
+	EndsAt     time.Time `gorm:"not null;index:idx_promotion_period,priority:3" json:"ends_at"`
+	CreatedBy  uint64    `gorm:"not null" json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	// StartRecorded and EndRecorded track which ends of the promotion are in
+	// the price history. A promotion that did not apply when it started is
+	// never recorded.
+	StartRecorded bool           `gorm:"not null;default:false" json:"-"`
+	EndRecorded   bool           `gorm:"not null;default:false" json:"-"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Price and PriceFormatted are derived from PriceMinor like a product's.
+	Price          float64 `gorm:"-" json:"price"`
+	PriceFormatted string  `gorm:"-" json:"price_formatted"`
+
+	Product Product `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Money returns the exact sale price.
+func (p *Promotion) Money() money.Money {
+	return money.Money{Amount: p.PriceMinor, Currency: p.Currency}
+}
+
+func (p *Promotion) SetMoney(m money.Money) {
+	p.PriceMinor = m.Amount
+	p.Currency = m.Currency
+	p.Price = m.Float()
+	p.PriceFormatted = m.String()
+}
+
+func (p *Promotion) AfterFind(tx *gorm.DB) error {
+	p.SetMoney(p.Money())
+	return nil
+}
+
+// Applies reports whether the sale price undercuts the product's price.
+func (p *Promotion) Applies(product *Product) bool {
+	return p.Currency == product.Currency && p.PriceMinor < product.PriceMinor
+}
+
+// Overlaps reports whether the two promotions run at the same time at any
+// point. A promotion may start when another ends.
+func (p *Promotion) Overlaps(other *Promotion) bool {
+	return p.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(p.EndsAt)
+}
+
+// Active reports whether the promotion runs at t.
+func (p *Promotion) Active(t time.Time) bool {
+	return !t.Before(p.StartsAt) && t.Before(p.EndsAt)
+}
@@ -0,0 +1,83 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromotionOverlaps(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	existing := &Promotion{StartsAt: day(10), EndsAt: day(20)}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  bool
+	}{
+		{"same period", day(10), day(20), true},
+		{"inside", day(12), day(15), true},
+		{"around", day(5), day(25), true},
+		{"starts during", day(15), day(25), true},
+		{"ends during", day(5), day(15), true},
+		{"ends when it starts", day(5), day(10), false},
+		{"starts when it ends", day(20), day(25), false},
+		{"before", day(1), day(5), false},
+		{"after", day(21), day(25), false},
+	}
+
+	for _, tt := range tests {
+		p := &Promotion{StartsAt: tt.start, EndsAt: tt.end}
+		if got := p.Overlaps(existing); got != tt.want {
+			t.Errorf("%s: Overlaps = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := existing.Overlaps(p); got != tt.want {
+			t.Errorf("%s: reversed Overlaps = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionActive(t *testing.T) {
+	start := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	p := &Promotion{StartsAt: start, EndsAt: start.Add(24 * time.Hour)}
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{start.Add(-time.Second), false},
+		{start, true},
+		{start.Add(12 * time.Hour), true},
+		{p.EndsAt.Add(-time.Second), true},
+		{p.EndsAt, false},
+	}
+
+	for _, tt := range tests {
+		if got := p.Active(tt.at); got != tt.want {
+			t.Errorf("Active(%v) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionApplies(t *testing.T) {
+	product := &Product{PriceMinor: 1000, Currency: "USD"}
+
+	tests := []struct {
+		name     string
+		price    int64
+		currency string
+		want     bool
+	}{
+		{"below the price", 999, "USD", true},
+		{"equal to the price", 1000, "USD", false},
+		{"above the price after a price cut", 1200, "USD", false},
+		{"after a currency change", 500, "EUR", false},
+	}
+
+	for _, tt := range tests {
+		p := &Promotion{PriceMinor: tt.price, Currency: tt.currency}
+		if got := p.Applies(product); got != tt.want {
+			t.Errorf("%s: Applies = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
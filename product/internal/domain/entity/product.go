@@ -1,13 +1,92 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"gorm.io/gorm"
+)
 
 type Product struct {
 	ID          uint   `gorm:"primarykey"`
 	Name        string `gorm:"not null"`
 	Description string
-	Price       float64 `gorm:"not null"`
-	UserID      uint64  `gorm:"not null"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// PriceMinor is the price in minor units of Currency, e.g. cents.
+	PriceMinor int64  `gorm:"not null"`
+	Currency   string `gorm:"size:3;not null;default:USD"`
+	// Price and PriceFormatted are derived from PriceMinor for display and
+	// for clients that predate exact prices; they are never stored.
+	Price          float64 `gorm:"-"`
+	PriceFormatted string  `gorm:"-"`
+	UserID         uint64  `gorm:"not null"`
+	// OrgID is set for products owned by an organization, whose members
+	// manage them according to their role.
+	OrgID *uint64 `gorm:"index"`
+	// Status is one of the Status constants; only published products are
+	// listed publicly.
+	Status string `gorm:"size:20;not null;default:draft;index"`
+	// PublishedAt is when the product was last published.
+	PublishedAt *time.Time
+	// ReviewNote explains why a moderator sent the product back to draft.
+	ReviewNote string
+	// OwnerHidden hides the product from listings while its owner's account
+	// is suspended, banned or deleted.
+	OwnerHidden bool `gorm:"not null;default:false;index" json:"-"`
+	// SearchVector is maintained by Postgres for full-text search; name
+	// matches rank above description matches.
+	SearchVector string `gorm:"->;type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector('english', coalesce(name, '')), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B')) STORED;index:,type:gin" json:"-"`
+	// Tags are stored in product_tags; nil leaves them untouched on update.
+	Tags []string `gorm:"-"`
+	// Attributes are validated against the schemas of the product's
+	// categories; nil leaves them untouched on update.
+	Attributes Attributes `gorm:"type:jsonb;not null;default:'{}'"`
+	// Images are stored in product_images, primary image first.
+	Images []*ProductImage `gorm:"-"`
+	// Promotion is the running promotion whose sale price applies, if any.
+	Promotion *Promotion `gorm:"-"`
+	// Rank is the full-text relevance, only loaded by searches sorted by it.
+	Rank float64 `gorm:"->;-:migration" json:"-"`
+	// EffectivePriceMinor is the price with any running promotion applied,
+	// only loaded by listings sorted by price.
+	EffectivePriceMinor int64 `gorm:"->;-:migration" json:"-"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	// DeletedAt is set while the product is in its owner's trash.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// Product statuses. Products start as drafts; publishing them requires a
+// moderator's approval when reviews are enabled.
+const (
+	StatusDraft         = "draft"
+	StatusPendingReview = "pending_review"
+	StatusPublished     = "published"
+	StatusArchived      = "archived"
+)
+
+// Money returns the exact price.
+func (p *Product) Money() money.Money {
+	return money.Money{Amount: p.PriceMinor, Currency: p.Currency}
+}
+
+// EffectiveMoney returns the price buyers pay: the sale price while a
+// promotion runs, the price otherwise.
+func (p *Product) EffectiveMoney() money.Money {
+	if p.Promotion != nil {
+		return p.Promotion.Money()
+	}
+	return p.Money()
+}
+
+// SetMoney sets the price and its derived fields.
+func (p *Product) SetMoney(m money.Money) {
+	p.PriceMinor = m.Amount
+	p.Currency = m.Currency
+	p.Price = m.Float()
+	p.PriceFormatted = m.String()
+}
+
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	p.SetMoney(p.Money())
+	return nil
 }
@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// Scheduled actions.
+const (
+	SchedulePublish   = "publish"
+	ScheduleUnpublish = "unpublish"
+	SchedulePrice     = "price_change"
+	// SchedulePriceRevert restores the price a price change replaced. It is
+	// created when the change runs, if the change has a RevertAt.
+	SchedulePriceRevert = "price_revert"
+)
+
+// Scheduled action statuses. Only pending actions run.
+const (
+	SchedulePending   = "pending"
+	ScheduleDone      = "done"
+	ScheduleFailed    = "failed"
+	ScheduleCancelled = "cancelled"
+)
+
+// ScheduledAction is a change to a product that runs at a set time on behalf
+// of the user who scheduled it.
+type ScheduledAction struct {
+	ID        uint   `gorm:"primarykey" json:"id"`
+	ProductID uint   `gorm:"not null;index" json:"product_id"`
+	Action    string `gorm:"size:20;not null" json:"action"`
+	// PriceMinor and Currency are the price a price change or revert sets.
+	PriceMinor *int64    `json:"price_minor,omitempty"`
+	Currency   string    `gorm:"size:3" json:"currency,omitempty"`
+	RunAt      time.Time `gorm:"not null;index:idx_schedule_due,priority:2" json:"run_at"`
+	// RevertAt is when a price change is undone, if ever.
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+	// RevertOf is the price change a revert undoes.
+	RevertOf   *uint      `gorm:"index" json:"revert_of,omitempty"`
+	Status     string     `gorm:"size:20;not null;default:pending;index:idx_schedule_due,priority:1" json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreatedBy  uint64     `gorm:"not null" json:"created_by"`
+	ExecutedAt *time.Time `json:"executed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Product Product `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+// Tag is a free-form label sellers attach to products.
+type Tag struct {
+	ID   uint   `gorm:"primarykey"`
+	Name string `gorm:"not null;uniqueIndex"`
+}
+
+// ProductTag attaches a tag to a product.
+type ProductTag struct {
+	ProductID uint `gorm:"primarykey"`
+	TagID     uint `gorm:"primarykey;index"`
+
+	Product Product `gorm:"constraint:OnDelete:CASCADE"`
+	Tag     Tag     `gorm:"constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Variant is a purchasable version of a product, such as a size or color.
+type Variant struct {
+	ID        uint `gorm:"primarykey" json:"id"`
+	ProductID uint `gorm:"not null;index" json:"product_id"`
+	// SellerID is the user who created the product and OrgID the
+	// organization owning it, if any. SKUs are unique per seller: among the
+	// user's personal products, or among the organization's products. This
+	// includes the variants of products in the trash.
+	SellerID uint64  `gorm:"not null;uniqueIndex:idx_variant_seller_sku,where:org_id IS NULL" json:"-"`
+	OrgID    *uint64 `gorm:"uniqueIndex:idx_variant_org_sku,where:org_id IS NOT NULL" json:"-"`
+	SKU      string  `gorm:"not null;uniqueIndex:idx_variant_seller_sku;uniqueIndex:idx_variant_org_sku" json:"sku"`
+	Options  Options `gorm:"type:jsonb;not null;default:'{}'" json:"options"`
+	// PriceOverrideMinor replaces the product price, in the product's
+	// currency, when set. The product's currency cannot change while any of
+	// its variants has an override.
+	PriceOverrideMinor *int64    `json:"price_override_minor"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Stock on hand and available for reservation, filled in from the
+	// inventory.
+	Stock     int `gorm:"-" json:"stock"`
+	Available int `gorm:"-" json:"available"`
+
+	// The effective price, filled in from the product and its running
+	// promotion, which discounts price overrides in proportion.
+	PriceMinor     int64  `gorm:"-" json:"price_minor"`
+	Currency       string `gorm:"-" json:"currency"`
+	PriceFormatted string `gorm:"-" json:"price_formatted"`
+	OnSale         bool   `gorm:"-" json:"on_sale"`
+
+	Product Product `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Options are the option values that distinguish a variant, e.g.
+// {"size": "M", "color": "red"}.
+type Options map[string]string
+
+func (o Options) Value() (driver.Value, error) {
+	if o == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(o)
+	return string(data), err
+}
+
+func (o *Options) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*o = Options{}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Options", value)
+	}
+	return json.Unmarshal(data, o)
+}
@@ -0,0 +1,140 @@
+// Package money represents monetary amounts exactly, as integer minor units
+// of an ISO 4217 currency.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// DefaultCurrency is assumed where no currency was given, and is the currency
+// of every price stored before currencies existed.
+const DefaultCurrency = "USD"
+
+// exponents maps the supported ISO 4217 currencies to the number of digits
+// of their minor unit.
+var exponents = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CNY": 2, "CZK": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "INR": 2, "JPY": 0,
+	"KRW": 0, "KWD": 3, "KZT": 2, "MXN": 2, "NOK": 2, "NZD": 2, "PLN": 2,
+	"RUB": 2, "SEK": 2, "SGD": 2, "TRY": 2, "UAH": 2, "USD": 2, "ZAR": 2,
+}
+
+// Money is an amount in minor units of a currency, e.g. 1250 USD is $12.50.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// ValidCurrency reports whether the currency code is supported.
+func ValidCurrency(currency string) bool {
+	_, ok := exponents[currency]
+	return ok
+}
+
+// Exponent returns the number of minor unit digits of the currency.
+func Exponent(currency string) int {
+	return exponents[currency]
+}
+
+// New returns an amount of minor units in the currency.
+func New(amount int64, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Parse reads a decimal amount in major units, such as "12.50". It rejects
+// more fractional digits than the currency has.
+func Parse(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	exp := exponents[currency]
+
+	amount = strings.TrimSpace(amount)
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, amount)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %s allows at most %d decimal places", ErrInvalidAmount, currency, exp)
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("%w %q", ErrInvalidAmount, amount)
+		}
+	}
+
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: out of range", ErrInvalidAmount)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// FromFloat rounds an amount in major units to the nearest minor unit of the
+// currency, for clients that predate exact amounts.
+func FromFloat(amount float64, currency string) (Money, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Money{}, fmt.Errorf("%w %v", ErrInvalidAmount, amount)
+	}
+	currency = strings.ToUpper(currency)
+	if !ValidCurrency(currency) {
+		return Money{}, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	return Parse(strconv.FormatFloat(amount, 'f', exponents[currency], 64), currency)
+}
+
+// Decimal formats the amount in major units, e.g. "12.50".
+func (m Money) Decimal() string {
+	exp := exponents[m.Currency]
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absInt(amount), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency code, e.g. "12.50 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Float approximates the amount in major units, for clients that predate
+// exact amounts.
+func (m Money) Float() float64 {
+	return float64(m.Amount) / math.Pow10(exponents[m.Currency])
+}
+
+func absInt(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
@@ -0,0 +1,118 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     Money
+		err      error
+	}{
+		{"12.50", "USD", Money{1250, "USD"}, nil},
+		{"12.5", "usd", Money{1250, "USD"}, nil},
+		{"12", "EUR", Money{1200, "EUR"}, nil},
+		{".5", "USD", Money{50, "USD"}, nil},
+		{"7.", "USD", Money{700, "USD"}, nil},
+		{" 0.01 ", "USD", Money{1, "USD"}, nil},
+		{"-3.25", "USD", Money{-325, "USD"}, nil},
+		{"1500", "JPY", Money{1500, "JPY"}, nil},
+		{"1.234", "KWD", Money{1234, "KWD"}, nil},
+		{"0.001", "USD", Money{}, ErrInvalidAmount},
+		{"1.5", "JPY", Money{}, ErrInvalidAmount},
+		{"", "USD", Money{}, ErrInvalidAmount},
+		{".", "USD", Money{}, ErrInvalidAmount},
+		{"1,50", "USD", Money{}, ErrInvalidAmount},
+		{"1e3", "USD", Money{}, ErrInvalidAmount},
+		{"--1", "USD", Money{}, ErrInvalidAmount},
+		{"99999999999999999999", "USD", Money{}, ErrInvalidAmount},
+		{"1.00", "XXX", Money{}, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.amount, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q, %q) error = %v, want %v", tt.amount, tt.currency, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     Money
+		err      error
+	}{
+		{0.1 + 0.2, "USD", Money{30, "USD"}, nil},
+		{19.99, "USD", Money{1999, "USD"}, nil},
+		{1.005, "USD", Money{100, "USD"}, nil},
+		{2.675, "EUR", Money{267, "EUR"}, nil},
+		{0.125, "USD", Money{12, "USD"}, nil},
+		{-4.2, "USD", Money{-420, "USD"}, nil},
+		{1499.6, "JPY", Money{1500, "JPY"}, nil},
+		{1.2345, "BHD", Money{1234, "BHD"}, nil},
+		{math.NaN(), "USD", Money{}, ErrInvalidAmount},
+		{math.Inf(1), "USD", Money{}, ErrInvalidAmount},
+		{1e30, "USD", Money{}, ErrInvalidAmount},
+		{1, "XXX", Money{}, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		got, err := FromFloat(tt.amount, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("FromFloat(%v, %q) error = %v, want %v", tt.amount, tt.currency, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FromFloat(%v, %q) = %+v, want %+v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{1250, "USD"}, "12.50"},
+		{Money{5, "USD"}, "0.05"},
+		{Money{0, "USD"}, "0.00"},
+		{Money{-5, "USD"}, "-0.05"},
+		{Money{-1999, "EUR"}, "-19.99"},
+		{Money{1500, "JPY"}, "1500"},
+		{Money{-7, "JPY"}, "-7"},
+		{Money{1, "KWD"}, "0.001"},
+		{Money{math.MinInt64, "USD"}, "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%+v.Decimal() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimalRoundTrip(t *testing.T) {
+	for _, m := range []Money{
+		{0, "USD"}, {1, "USD"}, {-1, "USD"}, {123456789, "EUR"},
+		{42, "JPY"}, {-42, "JPY"}, {1001, "BHD"}, {math.MaxInt64, "USD"},
+	} {
+		got, err := Parse(m.Decimal(), m.Currency)
+		if err != nil {
+			t.Errorf("Parse(%q) of %+v: %v", m.Decimal(), m, err)
+			continue
+		}
+		if got != m {
+			t.Errorf("Parse(%q) = %+v, want %+v", m.Decimal(), got, m)
+		}
+	}
+}
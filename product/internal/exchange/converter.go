@@ -0,0 +1,145 @@
+package exchange
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+)
+
+// Converter converts money with rates cached from a provider. Cached rates
+// are refreshed once older than the TTL; if refreshing fails, the stale
+// rates keep being used. Concurrent conversions share a single refresh.
+type Converter struct {
+	provider Provider
+	ttl      time.Duration
+	refresh  singleflight.Group
+
+	mu        sync.Mutex
+	rates     *Rates
+	fetchedAt time.Time
+}
+
+func NewConverter(provider Provider, ttl time.Duration) *Converter {
+	return &Converter{
+		provider: provider,
+		ttl:      ttl,
+	}
+}
+
+// Conversion is a converted amount along with the rate that produced it.
+type Conversion struct {
+	Money money.Money
+	// Rate is how many units of the target currency one unit of the source
+	// currency buys.
+	Rate   *big.Rat
+	Source string
+	AsOf   time.Time
+}
+
+// Convert converts m into the currency, rounding half away from zero to the
+// currency's minor unit.
+func (c *Converter) Convert(ctx context.Context, m money.Money, currency string) (*Conversion, error) {
+	rates, err := c.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := rates.Rate(m.Currency, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// amount * rate * 10^(target exponent - source exponent)
+	amount := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	scale := money.Exponent(currency) - money.Exponent(m.Currency)
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(scale))), nil))
+	if scale >= 0 {
+		amount.Mul(amount, pow)
+	} else {
+		amount.Quo(amount, pow)
+	}
+
+	return &Conversion{
+		Money:  money.Money{Amount: round(amount), Currency: currency},
+		Rate:   rate,
+		Source: rates.Source,
+		AsOf:   rates.AsOf,
+	}, nil
+}
+
+// Supports reports whether there is a rate for the currency.
+func (c *Converter) Supports(ctx context.Context, currency string) (bool, error) {
+	rates, err := c.current(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, ok := rates.Rates[currency]
+	return ok, nil
+}
+
+func (c *Converter) current(ctx context.Context) (*Rates, error) {
+	c.mu.Lock()
+	rates, fetchedAt := c.rates, c.fetchedAt
+	c.mu.Unlock()
+	if rates != nil && time.Since(fetchedAt) < c.ttl {
+		return rates, nil
+	}
+
+	// The fetch runs without the lock, on behalf of every caller waiting for
+	// it, so one caller going away must not cancel it
+	result, _, _ := c.refresh.Do("rates", func() (interface{}, error) {
+		return c.fetch(context.WithoutCancel(ctx)), nil
+	})
+	if rates, _ := result.(*Rates); rates != nil {
+		return rates, nil
+	}
+	return nil, ErrRatesUnavailable
+}
+
+// fetch refreshes the cached rates and returns them, or the stale rates if
+// refreshing fails. It returns nil when there are no rates at all.
+func (c *Converter) fetch(ctx context.Context) *Rates {
+	rates, err := c.provider.Fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if c.rates != nil {
+			log.Printf("Failed to refresh exchange rates, using rates from %s: %v", c.fetchedAt.Format(time.RFC3339), err)
+		} else {
+			log.Printf("Failed to fetch exchange rates: %v", err)
+		}
+		return c.rates
+	}
+
+	c.rates, c.fetchedAt = rates, time.Now()
+	return rates
+}
+
+// round rounds half away from zero.
+func round(r *big.Rat) int64 {
+	num, denom := r.Num(), r.Denom()
+	q, m := new(big.Int).QuoRem(num, denom, new(big.Int))
+	// Compare twice the remainder with the denominator
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(denom) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
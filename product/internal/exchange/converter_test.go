@@ -0,0 +1,149 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+)
+
+type staticProvider struct {
+	rates *Rates
+	err   error
+	calls int
+}
+
+func (p *staticProvider) Fetch(ctx context.Context) (*Rates, error) {
+	p.calls++
+	return p.rates, p.err
+}
+
+func testRates(t *testing.T) *Rates {
+	t.Helper()
+	rates, err := decodeRates(strings.NewReader(`{
+		"base": "usd",
+		"timestamp": "2024-01-01T00:00:00Z",
+		"rates": {"EUR": 0.9, "JPY": 150.5, "KWD": 0.307, "GBP": 0.8}
+	}`), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rates
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		num, denom int64
+		want       int64
+	}{
+		{10, 4, 3},
+		{-10, 4, -3},
+		{9, 4, 2},
+		{-9, 4, -2},
+		{11, 4, 3},
+		{-11, 4, -3},
+		{1, 3, 0},
+		{2, 3, 1},
+		{-2, 3, -1},
+		{7, 1, 7},
+		{0, 5, 0},
+	}
+
+	for _, tt := range tests {
+		if got := round(big.NewRat(tt.num, tt.denom)); got != tt.want {
+			t.Errorf("round(%d/%d) = %d, want %d", tt.num, tt.denom, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	c := NewConverter(&staticProvider{rates: testRates(t)}, time.Hour)
+
+	tests := []struct {
+		from money.Money
+		to   string
+		want money.Money
+	}{
+		{money.Money{Amount: 1000, Currency: "USD"}, "EUR", money.Money{Amount: 900, Currency: "EUR"}},
+		// 0.05 USD is 0.045 EUR, which rounds half away from zero
+		{money.Money{Amount: 5, Currency: "USD"}, "EUR", money.Money{Amount: 5, Currency: "EUR"}},
+		{money.Money{Amount: -5, Currency: "USD"}, "EUR", money.Money{Amount: -5, Currency: "EUR"}},
+		{money.Money{Amount: 1999, Currency: "USD"}, "JPY", money.Money{Amount: 3008, Currency: "JPY"}},
+		{money.Money{Amount: 3010, Currency: "JPY"}, "USD", money.Money{Amount: 2000, Currency: "USD"}},
+		{money.Money{Amount: 1000, Currency: "USD"}, "KWD", money.Money{Amount: 3070, Currency: "KWD"}},
+		{money.Money{Amount: 900, Currency: "EUR"}, "GBP", money.Money{Amount: 800, Currency: "GBP"}},
+		{money.Money{Amount: 1234, Currency: "EUR"}, "EUR", money.Money{Amount: 1234, Currency: "EUR"}},
+	}
+
+	for _, tt := range tests {
+		got, err := c.Convert(context.Background(), tt.from, tt.to)
+		if err != nil {
+			t.Errorf("Convert(%s, %s): %v", tt.from, tt.to, err)
+			continue
+		}
+		if got.Money != tt.want {
+			t.Errorf("Convert(%s, %s) = %s, want %s", tt.from, tt.to, got.Money, tt.want)
+		}
+		if got.Source != "test" {
+			t.Errorf("Convert(%s, %s) source = %q, want %q", tt.from, tt.to, got.Source, "test")
+		}
+	}
+}
+
+func TestConvertUnsupportedCurrency(t *testing.T) {
+	c := NewConverter(&staticProvider{rates: testRates(t)}, time.Hour)
+
+	for _, tt := range []struct {
+		from money.Money
+		to   string
+	}{
+		{money.Money{Amount: 100, Currency: "RUB"}, "USD"},
+		{money.Money{Amount: 100, Currency: "USD"}, "BHD"},
+	} {
+		if _, err := c.Convert(context.Background(), tt.from, tt.to); !errors.Is(err, ErrUnsupportedCurrency) {
+			t.Errorf("Convert(%s, %s) error = %v, want %v", tt.from, tt.to, err, ErrUnsupportedCurrency)
+		}
+	}
+
+	for currency, want := range map[string]bool{"USD": true, "EUR": true, "BHD": false} {
+		got, err := c.Supports(context.Background(), currency)
+		if err != nil || got != want {
+			t.Errorf("Supports(%s) = %v, %v, want %v", currency, got, err, want)
+		}
+	}
+}
+
+func TestConverterKeepsStaleRates(t *testing.T) {
+	provider := &staticProvider{rates: testRates(t)}
+	c := NewConverter(provider, time.Hour)
+	ctx := context.Background()
+
+	if _, err := c.Convert(ctx, money.Money{Amount: 100, Currency: "USD"}, "EUR"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Convert(ctx, money.Money{Amount: 100, Currency: "USD"}, "EUR"); err != nil {
+		t.Fatal(err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("provider fetched %d times within the TTL, want 1", provider.calls)
+	}
+
+	c.fetchedAt = time.Now().Add(-2 * time.Hour)
+	provider.rates, provider.err = nil, errors.New("provider down")
+	got, err := c.Convert(ctx, money.Money{Amount: 1000, Currency: "USD"}, "EUR")
+	if err != nil {
+		t.Fatalf("Convert with stale rates: %v", err)
+	}
+	if got.Money.Amount != 900 {
+		t.Errorf("Convert with stale rates = %s, want 9.00 EUR", got.Money)
+	}
+
+	empty := NewConverter(&staticProvider{err: errors.New("provider down")}, time.Hour)
+	if _, err := empty.Convert(ctx, money.Money{Amount: 100, Currency: "USD"}, "EUR"); !errors.Is(err, ErrRatesUnavailable) {
+		t.Errorf("Convert without rates error = %v, want %v", err, ErrRatesUnavailable)
+	}
+}
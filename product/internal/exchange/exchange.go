@@ -0,0 +1,89 @@
+// Package exchange converts money between currencies using rates from a
+// pluggable provider.
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRatesUnavailable    = errors.New("exchange rates unavailable")
+	ErrUnsupportedCurrency = errors.New("no exchange rate for currency")
+)
+
+// Rates are exchange rates relative to a base currency: one unit of Base
+// buys Rates[code] units of code.
+type Rates struct {
+	Base  string
+	Rates map[string]*big.Rat
+	// AsOf is when the rates were published, or fetched if the source does
+	// not say.
+	AsOf time.Time
+	// Source names the provider the rates came from.
+	Source string
+}
+
+// Provider fetches current exchange rates.
+type Provider interface {
+	Fetch(ctx context.Context) (*Rates, error)
+}
+
+// ratesDocument is the JSON format shared by the file and HTTP providers:
+//
+//	{"base": "USD", "timestamp": "2024-01-01T00:00:00Z", "rates": {"EUR": 0.92}}
+type ratesDocument struct {
+	Base      string                 `json:"base"`
+	Timestamp time.Time              `json:"timestamp"`
+	Rates     map[string]json.Number `json:"rates"`
+}
+
+func decodeRates(r io.Reader, source string) (*Rates, error) {
+	var doc ratesDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode rates from %s: %v", source, err)
+	}
+	if doc.Base == "" {
+		return nil, fmt.Errorf("rates from %s have no base currency", source)
+	}
+
+	rates := &Rates{
+		Base:   strings.ToUpper(doc.Base),
+		Rates:  make(map[string]*big.Rat, len(doc.Rates)+1),
+		AsOf:   doc.Timestamp,
+		Source: source,
+	}
+	// Parse rates from their decimal text so they stay exact
+	for code, value := range doc.Rates {
+		rate, ok := new(big.Rat).SetString(value.String())
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q for %s from %s", value, code, source)
+		}
+		rates.Rates[strings.ToUpper(code)] = rate
+	}
+	rates.Rates[rates.Base] = big.NewRat(1, 1)
+
+	if rates.AsOf.IsZero() {
+		rates.AsOf = time.Now()
+	}
+	return rates, nil
+}
+
+// Rate returns how many units of to one unit of from buys.
+func (r *Rates) Rate(from, to string) (*big.Rat, error) {
+	fromRate, ok := r.Rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnsupportedCurrency, from)
+	}
+	toRate, ok := r.Rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnsupportedCurrency, to)
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
@@ -0,0 +1,64 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// FileProvider reads rates from a JSON file, for fixed rates and local
+// development.
+type FileProvider struct {
+	path string
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Fetch(ctx context.Context) (*Rates, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return decodeRates(f, "file:"+p.path)
+}
+
+// HTTPProvider fetches rates in the same JSON format from a URL, such as a
+// local stand-in for an exchange rate service.
+type HTTPProvider struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewHTTPProvider(url string) *HTTPProvider {
+	return &HTTPProvider{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *HTTPProvider) Fetch(ctx context.Context) (*Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rates request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("rates request returned %d: %s", resp.StatusCode, body)
+	}
+
+	return decodeRates(resp.Body, p.url)
+}
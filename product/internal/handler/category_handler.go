@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *ProductHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.productService.ListCategories(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"categories": tree,
+	})
+}
+
+type CategoryRequest struct {
+	Name string `json:"name" validate:"required"`
+	// Slug is derived from the name when empty.
+	Slug     string `json:"slug"`
+	ParentID *uint  `json:"parent_id"`
+	// AttributeSchema replaces the category's schema when present.
+	AttributeSchema *entity.AttributeSchema `json:"attribute_schema"`
+}
+
+func (h *ProductHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var req CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	category, err := h.productService.CreateCategory(r.Context(), userID, req.Name, req.Slug, req.ParentID, schemaOrEmpty(req.AttributeSchema))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+func schemaOrEmpty(schema *entity.AttributeSchema) entity.AttributeSchema {
+	if schema == nil {
+		return entity.AttributeSchema{}
+	}
+	return *schema
+}
+
+func (h *ProductHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid category id", http.StatusBadRequest)
+		return
+	}
+
+	var req CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	category, err := h.productService.UpdateCategory(r.Context(), userID, uint(id), req.Name, req.Slug, req.ParentID, req.AttributeSchema)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (h *ProductHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid category id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	if err := h.productService.DeleteCategory(r.Context(), userID, uint(id)); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type SetProductCategoriesRequest struct {
+	CategoryIDs []uint `json:"category_ids"`
+}
+
+func (h *ProductHandler) SetProductCategories(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	var req SetProductCategoriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	categories, err := h.productService.SetProductCategories(r.Context(), id, userID, req.CategoryIDs)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"categories": categories,
+	})
+}
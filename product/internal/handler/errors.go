@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/product/internal/exchange"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+	"github.com/gauss2302/testcommm/product/internal/service"
+)
+
+// errorStatus maps service and repository errors to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrNotAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrCategoryNotFound), errors.Is(err, repository.ErrVariantNotFound),
+		errors.Is(err, repository.ErrReservationNotFound), errors.Is(err, repository.ErrImageNotFound),
+		errors.Is(err, repository.ErrScheduleNotFound), errors.Is(err, repository.ErrPromotionNotFound),
+		errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrCategoryExists), errors.Is(err, repository.ErrCategoryHasChildren),
+		errors.Is(err, repository.ErrSKUTaken), errors.Is(err, repository.ErrInsufficientStock),
+		errors.Is(err, repository.ErrReservationClosed), errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, repository.ErrScheduleClosed), errors.Is(err, repository.ErrPromotionOverlap),
+		errors.Is(err, repository.ErrPriceOverrides), errors.Is(err, repository.ErrOverrideCurrency):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrReservationExpired):
+		return http.StatusGone
+	case errors.Is(err, repository.ErrCategoryCycle), errors.Is(err, service.ErrInvalidCategory),
+		errors.Is(err, service.ErrInvalidFilter), errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrInvalidTag), errors.Is(err, service.ErrInvalidPrice),
+		errors.Is(err, service.ErrInvalidCurrency), errors.Is(err, exchange.ErrUnsupportedCurrency),
+		errors.Is(err, service.ErrInvalidVariant), errors.Is(err, service.ErrInvalidInventory),
+		errors.Is(err, service.ErrInvalidImage), errors.Is(err, service.ErrInvalidAttributes),
+		errors.Is(err, service.ErrInvalidSchedule), errors.Is(err, service.ErrInvalidPromotion):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrImageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, exchange.ErrRatesUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
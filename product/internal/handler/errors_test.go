@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/product/internal/exchange"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+	"github.com/gauss2302/testcommm/product/internal/service"
+)
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		err  error
+		http int
+		grpc codes.Code
+	}{
+		{service.ErrForbidden, http.StatusForbidden, codes.PermissionDenied},
+		{service.ErrNotAdmin, http.StatusForbidden, codes.PermissionDenied},
+
+		{gorm.ErrRecordNotFound, http.StatusNotFound, codes.NotFound},
+		{repository.ErrCategoryNotFound, http.StatusNotFound, codes.NotFound},
+		{repository.ErrVariantNotFound, http.StatusNotFound, codes.NotFound},
+		{repository.ErrReservationNotFound, http.StatusNotFound, codes.NotFound},
+		{repository.ErrImageNotFound, http.StatusNotFound, codes.NotFound},
+		{repository.ErrScheduleNotFound, http.StatusNotFound, codes.NotFound},
+		{repository.ErrPromotionNotFound, http.StatusNotFound, codes.NotFound},
+
+		{repository.ErrCategoryExists, http.StatusConflict, codes.AlreadyExists},
+		{repository.ErrSKUTaken, http.StatusConflict, codes.AlreadyExists},
+		{repository.ErrCategoryHasChildren, http.StatusConflict, codes.FailedPrecondition},
+		{repository.ErrInsufficientStock, http.StatusConflict, codes.FailedPrecondition},
+		{repository.ErrReservationClosed, http.StatusConflict, codes.FailedPrecondition},
+		{service.ErrInvalidTransition, http.StatusConflict, codes.FailedPrecondition},
+		{repository.ErrScheduleClosed, http.StatusConflict, codes.FailedPrecondition},
+		{repository.ErrPromotionOverlap, http.StatusConflict, codes.FailedPrecondition},
+		{repository.ErrPriceOverrides, http.StatusConflict, codes.FailedPrecondition},
+		{repository.ErrOverrideCurrency, http.StatusConflict, codes.FailedPrecondition},
+		{repository.ErrReservationExpired, http.StatusGone, codes.FailedPrecondition},
+
+		{repository.ErrCategoryCycle, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidCategory, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidFilter, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidCursor, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidTag, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidPrice, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidCurrency, http.StatusBadRequest, codes.InvalidArgument},
+		{exchange.ErrUnsupportedCurrency, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidVariant, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidInventory, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidImage, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidAttributes, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidSchedule, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrInvalidPromotion, http.StatusBadRequest, codes.InvalidArgument},
+		{service.ErrImageTooLarge, http.StatusRequestEntityTooLarge, codes.InvalidArgument},
+
+		{exchange.ErrRatesUnavailable, http.StatusServiceUnavailable, codes.Unavailable},
+		{errors.New("boom"), http.StatusInternalServerError, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		// Errors usually arrive wrapped with details
+		err := fmt.Errorf("%w: details", tt.err)
+		if got := errorStatus(err); got != tt.http {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.http)
+		}
+		if got := status.Code(grpcError(err)); got != tt.grpc {
+			t.Errorf("grpcError(%v) = %s, want %s", tt.err, got, tt.grpc)
+		}
+	}
+}
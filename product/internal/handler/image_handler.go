@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gauss2302/testcommm/product/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type ReorderImagesRequest struct {
+	ImageIDs []uint `json:"image_ids"`
+}
+
+// UploadImage accepts a multipart form with the image in its "image" field.
+func (h *ProductHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxImageSize+1<<20)
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, service.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "expected a multipart form with an image field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, service.MaxImageSize+1))
+	if err != nil {
+		http.Error(w, "failed to read image", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	image, err := h.productService.UploadImage(r.Context(), productID, userID, data, header.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(image)
+}
+
+func (h *ProductHandler) ListImages(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	images, err := h.productService.ListImages(r.Context(), productID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	writeImages(w, images)
+}
+
+func (h *ProductHandler) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req ReorderImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	images, err := h.productService.ReorderImages(r.Context(), productID, userID, req.ImageIDs)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	writeImages(w, images)
+}
+
+func (h *ProductHandler) SetPrimaryImage(w http.ResponseWriter, r *http.Request) {
+	productID, imageID, ok := imageParams(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	images, err := h.productService.SetPrimaryImage(r.Context(), productID, imageID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	writeImages(w, images)
+}
+
+func (h *ProductHandler) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	productID, imageID, ok := imageParams(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	if err := h.productService.DeleteImage(r.Context(), productID, imageID, userID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// imageParams reads the product and image IDs from the URL.
+func imageParams(w http.ResponseWriter, r *http.Request) (uint64, uint64, bool) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	imageID, err := strconv.ParseUint(chi.URLParam(r, "imageID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid image id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return productID, imageID, true
+}
+
+func writeImages(w http.ResponseWriter, images interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"images": images,
+	})
+}
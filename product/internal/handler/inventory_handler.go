@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdjustInventoryRequest struct {
+	VariantID uint64 `json:"variant_id"`
+	Delta     int    `json:"delta"`
+	Note      string `json:"note"`
+}
+
+type ReserveStockRequest struct {
+	ProductID uint64 `json:"product_id"`
+	VariantID uint64 `json:"variant_id"`
+	Quantity  int    `json:"quantity"`
+	// TTLSeconds defaults to 15 minutes.
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+func (h *ProductHandler) GetInventory(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	items, err := h.productService.GetInventory(r.Context(), productID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items": items,
+	})
+}
+
+func (h *ProductHandler) AdjustInventory(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req AdjustInventoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	item, err := h.productService.AdjustInventory(r.Context(), productID, req.VariantID, userID, req.Delta, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+func (h *ProductHandler) ListInventoryLedger(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	var variantID *uint64
+	if v := q.Get("variant_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid variant_id", http.StatusBadRequest)
+			return
+		}
+		variantID = &id
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	ledger, err := h.productService.ListInventoryLedger(r.Context(), productID, userID, variantID, int32(page), int32(perPage))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledger)
+}
+
+func (h *ProductHandler) ReserveStock(w http.ResponseWriter, r *http.Request) {
+	var req ReserveStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	reservation, err := h.productService.ReserveStock(r.Context(), req.ProductID, req.VariantID, userID, req.Quantity, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservation)
+}
+
+func (h *ProductHandler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	h.reservationAction(w, r, h.productService.GetReservation)
+}
+
+func (h *ProductHandler) CommitReservation(w http.ResponseWriter, r *http.Request) {
+	h.reservationAction(w, r, h.productService.CommitReservation)
+}
+
+func (h *ProductHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	h.reservationAction(w, r, h.productService.ReleaseReservation)
+}
+
+// reservationAction runs action on the reservation in the URL on behalf of
+// the caller and writes the resulting reservation.
+func (h *ProductHandler) reservationAction(w http.ResponseWriter, r *http.Request, action func(context.Context, uint64, uint64) (*entity.Reservation, error)) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid reservation id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	reservation, err := action(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
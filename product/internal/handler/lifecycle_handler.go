@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/go-chi/chi/v5"
+)
+
+type ReviewRequest struct {
+	// Note tells the owner why the product was rejected.
+	Note string `json:"note"`
+}
+
+func (h *ProductHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	h.statusAction(w, r, h.productService.PublishProduct)
+}
+
+func (h *ProductHandler) Unpublish(w http.ResponseWriter, r *http.Request) {
+	h.statusAction(w, r, h.productService.UnpublishProduct)
+}
+
+func (h *ProductHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.statusAction(w, r, h.productService.ArchiveProduct)
+}
+
+func (h *ProductHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.statusAction(w, r, func(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+		return h.productService.ReviewProduct(ctx, id, userID, true, "")
+	})
+}
+
+func (h *ProductHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	var req ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	h.statusAction(w, r, func(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+		return h.productService.ReviewProduct(ctx, id, userID, false, req.Note)
+	})
+}
+
+// ListPending returns the products awaiting moderation.
+func (h *ProductHandler) ListPending(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint64)
+
+	page, err := h.productService.ListPendingProducts(r.Context(), userID, parsePageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	responses, err := h.toResponses(r, page.Products)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	writePage(w, responses, page)
+}
+
+// statusAction runs a status change of the product in the URL on behalf of
+// the caller and writes the updated product.
+func (h *ProductHandler) statusAction(w http.ResponseWriter, r *http.Request, action func(context.Context, uint64, uint64) (*entity.Product, error)) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	product, err := action(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
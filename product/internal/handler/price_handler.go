@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// PromotionRequest puts a product on sale. The sale price is given like a
+// product price; times are RFC 3339.
+type PromotionRequest struct {
+	PriceRequest
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+func (h *ProductHandler) ListPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	history, err := h.productService.ListPriceHistory(r.Context(), productID, userID, int32(page), int32(perPage))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+func (h *ProductHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	var req PromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	price, err := req.Money()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	promotion, err := h.productService.CreatePromotion(r.Context(), productID, userID, service.PromotionInput{
+		Price:    price,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(promotion)
+}
+
+// ListPromotions returns the running and upcoming promotions of a product.
+func (h *ProductHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	promotions, err := h.productService.ListPromotions(r.Context(), productID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"promotions": promotions,
+	})
+}
+
+func (h *ProductHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.ParseUint(chi.URLParam(r, "promotionID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid promotion id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	if err := h.productService.DeletePromotion(r.Context(), productID, id, userID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
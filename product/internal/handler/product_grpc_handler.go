@@ -0,0 +1,450 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/exchange"
+	"github.com/gauss2302/testcommm/product/internal/internal/metrics"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+	"github.com/gauss2302/testcommm/product/internal/service"
+	pb "github.com/gauss2302/testcommm/product/proto/product"
+)
+
+// ProductGRPCHandler serves the ProductService gRPC API on top of the same
+// service as the HTTP handlers.
+type ProductGRPCHandler struct {
+	pb.UnimplementedProductServiceServer
+	productService *service.ProductService
+}
+
+func NewProductGRPCHandler(productService *service.ProductService) *ProductGRPCHandler {
+	return &ProductGRPCHandler{
+		productService: productService,
+	}
+}
+
+func (h *ProductGRPCHandler) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	price, err := protoPrice(req.Price, req.PriceMinor, req.Currency)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	product, err := h.productService.CreateProduct(ctx, service.ProductInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       price,
+		Tags:        req.Tags,
+		Attributes:  req.Attributes.AsMap(),
+	}, ctxUserID(ctx), req.OrgId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.ProductsCreatedTotal.Inc()
+
+	return productToProto(product), nil
+}
+
+func (h *ProductGRPCHandler) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
+	product, err := h.productService.ViewProduct(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := productToProto(product)
+	if err := h.convertPrices(ctx, []*entity.Product{product}, []*pb.Product{resp}, req.Currency); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (h *ProductGRPCHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	filter := repository.ProductFilter{
+		Query:        strings.TrimSpace(req.Query),
+		OwnerID:      req.OwnerId,
+		Category:     req.Category,
+		Tags:         req.Tags,
+		MatchAllTags: req.MatchAllTags,
+		SortBy:       req.SortBy,
+		Descending:   req.Descending,
+	}
+	for _, attr := range req.Attributes {
+		if filter.Attributes == nil {
+			filter.Attributes = make(map[string][]string)
+		}
+		filter.Attributes[attr.Name] = append(filter.Attributes[attr.Name], attr.Values...)
+	}
+	if req.MinPrice > 0 || req.MaxPrice > 0 || req.PriceCurrency != "" {
+		min, err := protoPrice(req.MinPrice, 0, req.PriceCurrency)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid min_price: %v", err)
+		}
+		max, err := protoPrice(req.MaxPrice, 0, req.PriceCurrency)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid max_price: %v", err)
+		}
+		filter.MinPrice, filter.MaxPrice, filter.Currency = min.Amount, max.Amount, min.Currency
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseTime(req.CreatedAfter); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid created_after")
+	}
+	if filter.CreatedBefore, err = parseTime(req.CreatedBefore); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid created_before")
+	}
+
+	page, err := h.productService.ListProducts(ctx, filter, service.PageRequest{
+		Page:      req.Page,
+		PerPage:   req.PerPage,
+		Cursor:    req.Cursor,
+		SkipCount: req.SkipTotal,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := listToProto(page)
+	if err := h.convertPrices(ctx, page.Products, resp.Products, req.Currency); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (h *ProductGRPCHandler) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	price, err := protoPrice(req.Price, req.PriceMinor, req.Currency)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	input := service.ProductInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       price,
+	}
+	if req.SetTags {
+		input.Tags = append([]string{}, req.Tags...)
+	}
+	if req.Attributes != nil {
+		input.Attributes = req.Attributes.AsMap()
+	}
+
+	product, err := h.productService.UpdateProduct(ctx, req.Id, ctxUserID(ctx), input)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.ProductsUpdatedTotal.Inc()
+
+	return productToProto(product), nil
+}
+
+func (h *ProductGRPCHandler) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	if err := h.productService.DeleteProduct(ctx, req.Id, ctxUserID(ctx)); err != nil {
+		return nil, grpcError(err)
+	}
+	metrics.ProductsDeletedTotal.Inc()
+
+	return &pb.DeleteProductResponse{}, nil
+}
+
+func (h *ProductGRPCHandler) ListUserProducts(ctx context.Context, req *pb.ListUserProductsRequest) (*pb.ListProductsResponse, error) {
+	page, err := h.productService.ListUserProducts(ctx, ctxUserID(ctx), req.Status, service.PageRequest{
+		Page:      req.Page,
+		PerPage:   req.PerPage,
+		Cursor:    req.Cursor,
+		SkipCount: req.SkipTotal,
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := listToProto(page)
+	if err := h.convertPrices(ctx, page.Products, resp.Products, req.Currency); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (h *ProductGRPCHandler) GetInventory(ctx context.Context, req *pb.GetInventoryRequest) (*pb.GetInventoryResponse, error) {
+	items, err := h.productService.GetInventory(ctx, req.ProductId, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &pb.GetInventoryResponse{Items: make([]*pb.InventoryItem, len(items))}
+	for i, item := range items {
+		resp.Items[i] = inventoryItemToProto(item)
+	}
+	return resp, nil
+}
+
+func (h *ProductGRPCHandler) AdjustInventory(ctx context.Context, req *pb.AdjustInventoryRequest) (*pb.InventoryItem, error) {
+	item, err := h.productService.AdjustInventory(ctx, req.ProductId, req.VariantId, ctxUserID(ctx), int(req.Delta), req.Note)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return inventoryItemToProto(item), nil
+}
+
+func (h *ProductGRPCHandler) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest) (*pb.Reservation, error) {
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	reservation, err := h.productService.ReserveStock(ctx, req.ProductId, req.VariantId, ctxUserID(ctx), int(req.Quantity), ttl)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return reservationToProto(reservation), nil
+}
+
+func (h *ProductGRPCHandler) GetReservation(ctx context.Context, req *pb.ReservationRequest) (*pb.Reservation, error) {
+	reservation, err := h.productService.GetReservation(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return reservationToProto(reservation), nil
+}
+
+func (h *ProductGRPCHandler) CommitReservation(ctx context.Context, req *pb.ReservationRequest) (*pb.Reservation, error) {
+	reservation, err := h.productService.CommitReservation(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return reservationToProto(reservation), nil
+}
+
+func (h *ProductGRPCHandler) ReleaseReservation(ctx context.Context, req *pb.ReservationRequest) (*pb.Reservation, error) {
+	reservation, err := h.productService.ReleaseReservation(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return reservationToProto(reservation), nil
+}
+
+func (h *ProductGRPCHandler) PublishProduct(ctx context.Context, req *pb.ProductStatusRequest) (*pb.Product, error) {
+	product, err := h.productService.PublishProduct(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+func (h *ProductGRPCHandler) UnpublishProduct(ctx context.Context, req *pb.ProductStatusRequest) (*pb.Product, error) {
+	product, err := h.productService.UnpublishProduct(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+func (h *ProductGRPCHandler) ArchiveProduct(ctx context.Context, req *pb.ProductStatusRequest) (*pb.Product, error) {
+	product, err := h.productService.ArchiveProduct(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+func (h *ProductGRPCHandler) ReviewProduct(ctx context.Context, req *pb.ReviewProductRequest) (*pb.Product, error) {
+	product, err := h.productService.ReviewProduct(ctx, req.Id, ctxUserID(ctx), req.Approve, req.Note)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+func (h *ProductGRPCHandler) RestoreProduct(ctx context.Context, req *pb.ProductStatusRequest) (*pb.Product, error) {
+	product, err := h.productService.RestoreProduct(ctx, req.Id, ctxUserID(ctx))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+// convertPrices fills in the converted prices of the products when a
+// currency was requested.
+func (h *ProductGRPCHandler) convertPrices(ctx context.Context, products []*entity.Product, protos []*pb.Product, currency string) error {
+	if currency == "" {
+		return nil
+	}
+
+	converted, err := h.productService.ConvertPrices(ctx, products, currency)
+	if err != nil {
+		return grpcError(err)
+	}
+	for i, product := range products {
+		if c, ok := converted[product.ID]; ok {
+			protos[i].ConvertedPrice = &pb.ConvertedPrice{
+				AmountMinor: c.AmountMinor,
+				Currency:    c.Currency,
+				Formatted:   c.Formatted,
+				Rate:        c.Rate,
+				Source:      c.Source,
+				RatesAsOf:   c.RatesAsOf.Format(time.RFC3339),
+			}
+		}
+	}
+	return nil
+}
+
+func ctxUserID(ctx context.Context) uint64 {
+	return ctx.Value("user_id").(uint64)
+}
+
+// grpcError maps service and repository errors to gRPC status codes, as
+// errorStatus does to HTTP ones.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrNotAdmin):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, repository.ErrCategoryNotFound), errors.Is(err, repository.ErrVariantNotFound),
+		errors.Is(err, repository.ErrReservationNotFound), errors.Is(err, repository.ErrImageNotFound),
+		errors.Is(err, repository.ErrScheduleNotFound), errors.Is(err, repository.ErrPromotionNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "product not found")
+	case errors.Is(err, repository.ErrCategoryExists), errors.Is(err, repository.ErrSKUTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrCategoryHasChildren), errors.Is(err, repository.ErrInsufficientStock),
+		errors.Is(err, repository.ErrReservationClosed), errors.Is(err, repository.ErrReservationExpired),
+		errors.Is(err, service.ErrInvalidTransition), errors.Is(err, repository.ErrScheduleClosed),
+		errors.Is(err, repository.ErrPromotionOverlap), errors.Is(err, repository.ErrPriceOverrides),
+		errors.Is(err, repository.ErrOverrideCurrency):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, repository.ErrCategoryCycle), errors.Is(err, service.ErrInvalidCategory),
+		errors.Is(err, service.ErrInvalidFilter), errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrInvalidTag), errors.Is(err, service.ErrInvalidPrice),
+		errors.Is(err, service.ErrInvalidCurrency), errors.Is(err, exchange.ErrUnsupportedCurrency),
+		errors.Is(err, service.ErrInvalidVariant), errors.Is(err, service.ErrInvalidInventory),
+		errors.Is(err, service.ErrInvalidImage), errors.Is(err, service.ErrInvalidAttributes),
+		errors.Is(err, service.ErrInvalidSchedule), errors.Is(err, service.ErrInvalidPromotion),
+		errors.Is(err, service.ErrImageTooLarge):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, exchange.ErrRatesUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func productToProto(product *entity.Product) *pb.Product {
+	images := make([]*pb.ProductImage, len(product.Images))
+	for i, image := range product.Images {
+		images[i] = &pb.ProductImage{
+			Id:          uint64(image.ID),
+			Url:         image.URL,
+			Thumbnails:  image.Thumbnails,
+			IsPrimary:   image.IsPrimary,
+			Position:    int32(image.Position),
+			Width:       int32(image.Width),
+			Height:      int32(image.Height),
+			ContentType: image.ContentType,
+		}
+	}
+
+	// Attributes only hold JSON scalars, which always convert
+	attributes, _ := structpb.NewStruct(product.Attributes)
+
+	var promotion *pb.Promotion
+	if p := product.Promotion; p != nil {
+		promotion = &pb.Promotion{
+			Id:             uint64(p.ID),
+			PriceMinor:     p.PriceMinor,
+			Currency:       p.Currency,
+			PriceFormatted: p.PriceFormatted,
+			StartsAt:       p.StartsAt.Format(time.RFC3339),
+			EndsAt:         p.EndsAt.Format(time.RFC3339),
+		}
+	}
+
+	var publishedAt string
+	if product.PublishedAt != nil {
+		publishedAt = product.PublishedAt.Format(time.RFC3339)
+	}
+
+	return &pb.Product{
+		Id:             uint64(product.ID),
+		Name:           product.Name,
+		Description:    product.Description,
+		Price:          product.Price,
+		PriceMinor:     product.PriceMinor,
+		Currency:       product.Currency,
+		PriceFormatted: product.PriceFormatted,
+		UserId:         product.UserID,
+		OrgId:          product.OrgID,
+		Tags:           product.Tags,
+		Images:         images,
+		Attributes:     attributes,
+		Status:         product.Status,
+		PublishedAt:    publishedAt,
+		ReviewNote:     product.ReviewNote,
+		Promotion:      promotion,
+		CreatedAt:      product.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      product.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// protoPrice reads a price given either in minor units or, for older
+// clients, as a double in major units. The double is converted through its
+// shortest decimal form, so 19.99 becomes exactly 1999 cents.
+func protoPrice(price float64, minor int64, currency string) (money.Money, error) {
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if minor != 0 {
+		return money.New(minor, currency)
+	}
+	return money.FromFloat(price, currency)
+}
+
+func inventoryItemToProto(item *entity.InventoryItem) *pb.InventoryItem {
+	return &pb.InventoryItem{
+		ProductId: uint64(item.ProductID),
+		VariantId: uint64(item.VariantID),
+		OnHand:    int32(item.OnHand),
+		Reserved:  int32(item.Reserved),
+		Available: int32(item.Available),
+		UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func reservationToProto(reservation *entity.Reservation) *pb.Reservation {
+	return &pb.Reservation{
+		Id:        uint64(reservation.ID),
+		ProductId: uint64(reservation.ProductID),
+		VariantId: uint64(reservation.VariantID),
+		Quantity:  int32(reservation.Quantity),
+		Status:    reservation.Status,
+		UserId:    reservation.UserID,
+		ExpiresAt: reservation.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: reservation.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func listToProto(page *service.ProductPage) *pb.ListProductsResponse {
+	resp := &pb.ListProductsResponse{
+		Products:   make([]*pb.Product, len(page.Products)),
+		Total:      int32(page.Total),
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+	for i, product := range page.Products {
+		resp.Products[i] = productToProto(product)
+	}
+	return resp
+}
@@ -2,10 +2,17 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gauss2302/testcommm/product/internal/internal/metrics"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/repository"
 	"github.com/gauss2302/testcommm/product/internal/service"
 	"github.com/go-chi/chi/v5"
 )
@@ -20,10 +27,87 @@ func NewProductHandler(productService *service.ProductService) *ProductHandler {
 	}
 }
 
+// productResponse is the JSON representation of a product, optionally
+// embedding related resources requested with the expand query parameter and
+// the price converted into the currency query parameter.
+type productResponse struct {
+	*entity.Product
+	Owner          *service.Owner                 `json:"owner,omitempty"`
+	Categories     []*service.ProductCategoryInfo `json:"categories,omitempty"`
+	ConvertedPrice *service.ConvertedPrice        `json:"converted_price,omitempty"`
+}
+
+func (h *ProductHandler) toResponses(r *http.Request, products []*entity.Product) ([]*productResponse, error) {
+	responses := make([]*productResponse, len(products))
+	for i, product := range products {
+		responses[i] = &productResponse{Product: product}
+	}
+
+	if expands(r, "owner") {
+		owners, err := h.productService.GetOwners(r.Context(), products)
+		if err != nil {
+			return nil, err
+		}
+		for _, resp := range responses {
+			resp.Owner = owners[resp.UserID]
+		}
+	}
+
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		converted, err := h.productService.ConvertPrices(r.Context(), products, currency)
+		if err != nil {
+			return nil, err
+		}
+		for _, resp := range responses {
+			resp.ConvertedPrice = converted[resp.ID]
+		}
+	}
+
+	return responses, nil
+}
+
+// expands reports whether the comma-separated expand query parameter
+// contains field.
+func expands(r *http.Request, field string) bool {
+	for _, value := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		if strings.TrimSpace(value) == field {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceRequest is the price of a product in a request. Price is a decimal
+// amount in major units of Currency, e.g. 12.50; PriceMinor gives the amount
+// in minor units instead and takes precedence. Currency defaults to USD.
+type PriceRequest struct {
+	Price      json.Number `json:"price"`
+	PriceMinor *int64      `json:"price_minor"`
+	Currency   string      `json:"currency"`
+}
+
+func (p PriceRequest) Money() (money.Money, error) {
+	currency := p.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if p.PriceMinor != nil {
+		return money.New(*p.PriceMinor, currency)
+	}
+	return money.Parse(p.Price.String(), currency)
+}
+
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	PriceRequest
+	// OrgID creates the product on behalf of an organization.
+	OrgID      *uint64           `json:"org_id"`
+	Tags       []string          `json:"tags"`
+	Attributes entity.Attributes `json:"attributes"`
+	// CategoryIDs are the categories whose schemas the attributes must
+	// satisfy.
+	CategoryIDs []uint `json:"category_ids"`
 }
 
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -33,11 +117,24 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	price, err := req.Money()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	userID := r.Context().Value("user_id").(uint64)
 
-	product, err := h.productService.CreateProduct(r.Context(), req.Name, req.Description, req.Price, userID)
+	product, err := h.productService.CreateProduct(r.Context(), service.ProductInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       price,
+		Tags:        req.Tags,
+		Attributes:  req.Attributes,
+		CategoryIDs: req.CategoryIDs,
+	}, userID, req.OrgID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
@@ -55,46 +152,170 @@ func (h *ProductHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.productService.GetProduct(r.Context(), id)
+	userID := r.Context().Value("user_id").(uint64)
+
+	product, err := h.productService.ViewProduct(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	responses, err := h.toResponses(r, []*entity.Product{product})
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	responses[0].Categories, err = h.productService.GetProductCategories(r.Context(), id, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	json.NewEncoder(w).Encode(responses[0])
 }
 
 func (h *ProductHandler) List(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
-	if perPage < 1 {
-		perPage = 10
+	page, err := h.productService.ListProducts(r.Context(), filter, parsePageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
 	}
 
-	products, total, err := h.productService.ListProducts(r.Context(), int32(page), int32(perPage))
+	responses, err := h.toResponses(r, page.Products)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
+	writePage(w, responses, page)
+}
+
+// parsePageRequest reads page and per_page, or a cursor from a previous
+// response. count=false skips counting the total and facets=true adds facet
+// counts.
+func parsePageRequest(r *http.Request) service.PageRequest {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+
+	return service.PageRequest{
+		Page:      int32(page),
+		PerPage:   int32(perPage),
+		Cursor:    q.Get("cursor"),
+		SkipCount: q.Get("count") == "false",
+		Facets:    q.Get("facets") == "true",
+	}
+}
+
+func writePage(w http.ResponseWriter, products interface{}, page *service.ProductPage) {
+	body := map[string]interface{}{
+		"products":    products,
+		"page":        page.Page,
+		"per_page":    page.PerPage,
+		"next_cursor": page.NextCursor,
+		"prev_cursor": page.PrevCursor,
+	}
+	if page.Total >= 0 {
+		body["total"] = page.Total
+	}
+	if page.Facets != nil {
+		body["facets"] = page.Facets
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"products": products,
-		"total":    total,
-		"page":     page,
-		"per_page": perPage,
-	})
+	json.NewEncoder(w).Encode(body)
+}
+
+// parseProductFilter reads the listing filters from the query string: q,
+// min_price and max_price (decimals in price_currency, default USD),
+// owner_id, category (slug), tags (comma-separated, tags_match=all to require
+// every tag), created_after and created_before (RFC 3339), sort and
+// order=desc.
+func parseProductFilter(r *http.Request) (repository.ProductFilter, error) {
+	q := r.URL.Query()
+	filter := repository.ProductFilter{
+		Query:        strings.TrimSpace(q.Get("q")),
+		Category:     q.Get("category"),
+		MatchAllTags: q.Get("tags_match") == "all",
+		SortBy:       q.Get("sort"),
+		Descending:   q.Get("order") == "desc",
+	}
+	if v := q.Get("tags"); v != "" {
+		filter.Tags = strings.Split(v, ",")
+	}
+	// Attribute filters look like attr.material=cotton; repeating one
+	// matches any of its values
+	for key, values := range q {
+		if name, ok := strings.CutPrefix(key, "attr."); ok {
+			if filter.Attributes == nil {
+				filter.Attributes = make(map[string][]string)
+			}
+			filter.Attributes[name] = values
+		}
+	}
+
+	var err error
+	currency := q.Get("price_currency")
+	if currency != "" || q.Get("min_price") != "" || q.Get("max_price") != "" {
+		if currency == "" {
+			currency = money.DefaultCurrency
+		}
+		if !money.ValidCurrency(strings.ToUpper(currency)) {
+			return filter, fmt.Errorf("invalid price_currency")
+		}
+		filter.Currency = strings.ToUpper(currency)
+	}
+	if v := q.Get("min_price"); v != "" {
+		price, err := money.Parse(v, filter.Currency)
+		if err != nil {
+			return filter, fmt.Errorf("invalid min_price: %v", err)
+		}
+		filter.MinPrice = price.Amount
+	}
+	if v := q.Get("max_price"); v != "" {
+		price, err := money.Parse(v, filter.Currency)
+		if err != nil {
+			return filter, fmt.Errorf("invalid max_price: %v", err)
+		}
+		filter.MaxPrice = price.Amount
+	}
+	if v := q.Get("owner_id"); v != "" {
+		if filter.OwnerID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return filter, fmt.Errorf("invalid owner_id")
+		}
+	}
+	if filter.CreatedAfter, err = parseTime(q.Get("created_after")); err != nil {
+		return filter, fmt.Errorf("invalid created_after")
+	}
+	if filter.CreatedBefore, err = parseTime(q.Get("created_before")); err != nil {
+		return filter, fmt.Errorf("invalid created_before")
+	}
+
+	return filter, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
 
 type UpdateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	PriceRequest
+	// Tags and Attributes replace the current ones when present.
+	Tags       []string          `json:"tags"`
+	Attributes entity.Attributes `json:"attributes"`
 }
 
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -116,14 +337,22 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	price, err := req.Money()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Update product
-	product, err := h.productService.UpdateProduct(r.Context(), id, userID, req.Name, req.Description, req.Price)
+	product, err := h.productService.UpdateProduct(r.Context(), id, userID, service.ProductInput{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       price,
+		Tags:        req.Tags,
+		Attributes:  req.Attributes,
+	})
 	if err != nil {
-		if err.Error() == "product does not belong to user" {
-			http.Error(w, err.Error(), http.StatusForbidden)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 	// Add metrics to track
@@ -147,7 +376,7 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	// Delete product
 	if err := h.productService.DeleteProduct(r.Context(), id, userID); err != nil {
-		if err.Error() == "product does not belong to user" {
+		if errors.Is(err, service.ErrForbidden) {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
@@ -161,31 +390,21 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ProductHandler) ListUserProducts(w http.ResponseWriter, r *http.Request) {
-	// Get pagination parameters
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
-	}
-	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
-	if perPage < 1 {
-		perPage = 10
-	}
-
 	// Get user ID from context
 	userID := r.Context().Value("user_id").(uint64)
 
-	// Get user's products
-	products, total, err := h.productService.ListUserProducts(r.Context(), userID, int32(page), int32(perPage))
+	// Get user's products, optionally in one status
+	page, err := h.productService.ListUserProducts(r.Context(), userID, r.URL.Query().Get("status"), parsePageRequest(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"products": products,
-		"total":    total,
-		"page":     page,
-		"per_page": perPage,
-	})
+	responses, err := h.toResponses(r, page.Products)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	writePage(w, responses, page)
 }
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// ScheduleRequest schedules an action on a product. Times are RFC 3339; a
+// price change takes the new price like a product does and may be reverted
+// at revert_at.
+type ScheduleRequest struct {
+	Action   string        `json:"action"`
+	RunAt    time.Time     `json:"run_at"`
+	Price    *PriceRequest `json:"price"`
+	RevertAt *time.Time    `json:"revert_at"`
+}
+
+func (h *ProductHandler) ScheduleAction(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	input := service.ScheduleInput{
+		Action:   req.Action,
+		RunAt:    req.RunAt,
+		RevertAt: req.RevertAt,
+	}
+	if req.Price != nil {
+		var price money.Money
+		if price, err = req.Price.Money(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		input.Price = &price
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	action, err := h.productService.ScheduleAction(r.Context(), productID, userID, input)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(action)
+}
+
+// ListSchedules returns the scheduled actions of a product, optionally only
+// those with the given status.
+func (h *ProductHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	actions, err := h.productService.ListSchedules(r.Context(), productID, userID, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"schedules": actions,
+	})
+}
+
+func (h *ProductHandler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.ParseUint(chi.URLParam(r, "scheduleID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid schedule id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	action, err := h.productService.CancelSchedule(r.Context(), productID, id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(action)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListTrash returns the authenticated user's deleted products that can still
+// be restored.
+func (h *ProductHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(uint64)
+
+	page, err := h.productService.ListTrash(r.Context(), userID, parsePageRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	responses, err := h.toResponses(r, page.Products)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	writePage(w, responses, page)
+}
+
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	h.statusAction(w, r, h.productService.RestoreProduct)
+}
+
+// Purge deletes a product for good. Only admins can.
+func (h *ProductHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	if err := h.productService.PurgeProduct(r.Context(), id, userID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
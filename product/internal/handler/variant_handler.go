@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// VariantRequest creates or replaces a variant. The price override is given
+// like a product price and defaults to the product's currency.
+type VariantRequest struct {
+	SKU                string            `json:"sku" validate:"required"`
+	Options            map[string]string `json:"options"`
+	PriceOverride      *json.Number      `json:"price_override"`
+	PriceOverrideMinor *int64            `json:"price_override_minor"`
+	Currency           string            `json:"currency"`
+	Stock              *int              `json:"stock"`
+}
+
+// input converts the request, resolving the override in the currency of the
+// product when none is given.
+func (req VariantRequest) input(currency string) (service.VariantInput, error) {
+	input := service.VariantInput{
+		SKU:     req.SKU,
+		Options: req.Options,
+		Stock:   req.Stock,
+	}
+	if req.Currency != "" {
+		currency = req.Currency
+	}
+
+	var price money.Money
+	var err error
+	switch {
+	case req.PriceOverrideMinor != nil:
+		price, err = money.New(*req.PriceOverrideMinor, currency)
+	case req.PriceOverride != nil:
+		price, err = money.Parse(req.PriceOverride.String(), currency)
+	default:
+		return input, nil
+	}
+	if err != nil {
+		return input, err
+	}
+	input.PriceOverride = &price
+	return input, nil
+}
+
+// variantParams reads the product and variant IDs from the URL.
+func variantParams(w http.ResponseWriter, r *http.Request) (uint64, uint64, bool) {
+	productID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid product id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	var variantID uint64
+	if v := chi.URLParam(r, "variantID"); v != "" {
+		if variantID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "invalid variant id", http.StatusBadRequest)
+			return 0, 0, false
+		}
+	}
+	return productID, variantID, true
+}
+
+func (h *ProductHandler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	variants, err := h.productService.ListVariants(r.Context(), productID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"variants": variants,
+	})
+}
+
+func (h *ProductHandler) GetVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	variant, err := h.productService.GetVariant(r.Context(), productID, variantID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(variant)
+}
+
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, _, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	input, ok := h.variantInput(w, r, productID)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	variant, err := h.productService.CreateVariant(r.Context(), productID, userID, input)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(variant)
+}
+
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	input, ok := h.variantInput(w, r, productID)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	variant, err := h.productService.UpdateVariant(r.Context(), productID, variantID, userID, input)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(variant)
+}
+
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := variantParams(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	if err := h.productService.DeleteVariant(r.Context(), productID, variantID, userID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// variantInput decodes the request body, pricing it in the product's
+// currency by default.
+func (h *ProductHandler) variantInput(w http.ResponseWriter, r *http.Request, productID uint64) (service.VariantInput, bool) {
+	var req VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return service.VariantInput{}, false
+	}
+
+	userID := r.Context().Value("user_id").(uint64)
+
+	product, err := h.productService.ViewProduct(r.Context(), productID, userID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return service.VariantInput{}, false
+	}
+
+	input, err := req.input(product.Currency)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return service.VariantInput{}, false
+	}
+	return input, true
+}
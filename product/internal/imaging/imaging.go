@@ -0,0 +1,165 @@
+// Package imaging decodes uploaded images and scales them down into
+// thumbnails using only the standard library.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"sort"
+)
+
+var ErrUnsupportedImage = errors.New("unsupported image")
+
+// MaxPixels bounds the decoded size of an image, protecting against small
+// files that decompress into huge bitmaps. At 4 bytes per pixel, scaling an
+// image this large takes about 100MB on top of the decoded image.
+const MaxPixels = 25_000_000
+
+// Decode decodes a JPEG, PNG or GIF image and returns it along with its
+// format name.
+func Decode(data []byte) (image.Image, string, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width*config.Height > MaxPixels {
+		return nil, "", fmt.Errorf("%w: %dx%d pixels is too large", ErrUnsupportedImage, config.Width, config.Height)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	return img, format, nil
+}
+
+// Thumbnails scales the image down for each of the named sizes so that
+// neither side exceeds the size, keeping its aspect ratio. Sizes the image
+// already fits are the image itself. The image is converted to RGBA once and
+// every thumbnail is scaled from the next larger one, so only one full-size
+// copy is ever allocated.
+func Thumbnails(img image.Image, sizes map[string]int) map[string]image.Image {
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return sizes[names[i]] > sizes[names[j]]
+	})
+
+	thumbs := make(map[string]image.Image, len(sizes))
+	var src *image.RGBA
+	for _, name := range names {
+		side := sizes[name]
+		bounds := img.Bounds()
+		if src != nil {
+			bounds = src.Bounds()
+		}
+		if bounds.Dx() <= side && bounds.Dy() <= side {
+			if src != nil {
+				thumbs[name] = src
+			} else {
+				thumbs[name] = img
+			}
+			continue
+		}
+
+		if src == nil {
+			src = toRGBA(img)
+		}
+		src = Thumbnail(src, side)
+		thumbs[name] = src
+	}
+	return thumbs
+}
+
+// Thumbnail scales the image down so that neither side exceeds maxSide,
+// keeping its aspect ratio. Images that already fit are returned unchanged.
+// Every thumbnail pixel averages the source pixels it covers, which keeps
+// downscaled detail smooth. The source is premultiplied RGBA so transparent
+// pixels average correctly.
+func Thumbnail(src *image.RGBA, maxSide int) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+
+	tw, th := maxSide, h*maxSide/w
+	if h > w {
+		tw, th = w*maxSide/h, maxSide
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, (y+1)*h/th
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, (x+1)*w/tw
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a uint64
+			for sy := y0; sy < y1; sy++ {
+				start := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				row := src.Pix[start : start+(x1-x0)*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+				}
+			}
+			n := uint64((x1 - x0) * (y1 - y0))
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// toRGBA returns the image as RGBA with its origin at zero, copying it
+// unless it already is.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// Encode encodes the image as PNG, which keeps transparency, when usePNG is
+// set and as JPEG otherwise. It returns the data and its content type.
+func Encode(img image.Image, usePNG bool) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if usePNG {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
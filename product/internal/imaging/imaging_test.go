@@ -0,0 +1,141 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func solid(rect image.Rectangle, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestThumbnail(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	tests := []struct {
+		name    string
+		src     image.Rectangle
+		maxSide int
+		want    image.Point
+	}{
+		{"fits", image.Rect(0, 0, 100, 50), 100, image.Pt(100, 50)},
+		{"landscape", image.Rect(0, 0, 400, 200), 100, image.Pt(100, 50)},
+		{"portrait", image.Rect(0, 0, 200, 400), 100, image.Pt(50, 100)},
+		{"square", image.Rect(0, 0, 300, 300), 64, image.Pt(64, 64)},
+		{"uneven", image.Rect(0, 0, 333, 100), 100, image.Pt(100, 30)},
+		{"thin", image.Rect(0, 0, 1000, 1), 10, image.Pt(10, 1)},
+		{"offset origin", image.Rect(50, 70, 450, 270), 100, image.Pt(100, 50)},
+	}
+
+	for _, tt := range tests {
+		src := solid(tt.src, red)
+		got := Thumbnail(src, tt.maxSide)
+		if size := got.Bounds().Size(); size != tt.want {
+			t.Errorf("%s: Thumbnail(%v, %d) size = %v, want %v", tt.name, tt.src, tt.maxSide, size, tt.want)
+			continue
+		}
+		// A solid image stays the same colour however it is averaged
+		for _, p := range []image.Point{got.Bounds().Min, got.Bounds().Max.Sub(image.Pt(1, 1))} {
+			if c := got.RGBAAt(p.X, p.Y); c != red {
+				t.Errorf("%s: pixel %v = %v, want %v", tt.name, p, c, red)
+			}
+		}
+	}
+}
+
+func TestThumbnailAverages(t *testing.T) {
+	// Left half opaque white, right half transparent
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+		}
+	}
+
+	tests := []struct {
+		maxSide int
+		want    []color.RGBA
+	}{
+		{2, []color.RGBA{{255, 255, 255, 255}, {0, 0, 0, 0}}},
+		{1, []color.RGBA{{127, 127, 127, 127}}},
+	}
+
+	for _, tt := range tests {
+		got := Thumbnail(src, tt.maxSide)
+		for x, want := range tt.want {
+			if c := got.RGBAAt(x, 0); c != want {
+				t.Errorf("Thumbnail(%d) pixel %d = %v, want %v", tt.maxSide, x, c, want)
+			}
+		}
+	}
+}
+
+func TestThumbnails(t *testing.T) {
+	sizes := map[string]int{"small": 50, "medium": 200, "large": 800}
+
+	tests := []struct {
+		name string
+		src  image.Rectangle
+		want map[string]image.Point
+	}{
+		{"larger than every size", image.Rect(0, 0, 1600, 800), map[string]image.Point{
+			"small": image.Pt(50, 25), "medium": image.Pt(200, 100), "large": image.Pt(800, 400),
+		}},
+		{"fits the largest", image.Rect(0, 0, 300, 600), map[string]image.Point{
+			"small": image.Pt(25, 50), "medium": image.Pt(100, 200), "large": image.Pt(300, 600),
+		}},
+		{"fits every size", image.Rect(0, 0, 40, 40), map[string]image.Point{
+			"small": image.Pt(40, 40), "medium": image.Pt(40, 40), "large": image.Pt(40, 40),
+		}},
+	}
+
+	for _, tt := range tests {
+		src := image.NewNRGBA(tt.src)
+		thumbs := Thumbnails(src, sizes)
+		if len(thumbs) != len(sizes) {
+			t.Errorf("%s: got %d thumbnails, want %d", tt.name, len(thumbs), len(sizes))
+		}
+		for name, want := range tt.want {
+			thumb, ok := thumbs[name]
+			if !ok {
+				t.Errorf("%s: missing %s thumbnail", tt.name, name)
+				continue
+			}
+			if size := thumb.Bounds().Size(); size != want {
+				t.Errorf("%s: %s thumbnail size = %v, want %v", tt.name, name, size, want)
+			}
+		}
+		if thumbs["large"] == image.Image(src) && tt.src.Dx() > sizes["large"] {
+			t.Errorf("%s: large thumbnail is the source image", tt.name)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	img, format, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode(png): %v", err)
+	}
+	if format != "png" || img.Bounds().Size() != image.Pt(3, 2) {
+		t.Errorf("Decode(png) = %s %v, want png (3,2)", format, img.Bounds().Size())
+	}
+
+	if _, _, err := Decode([]byte("not an image")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("Decode(garbage) error = %v, want %v", err, ErrUnsupportedImage)
+	}
+}
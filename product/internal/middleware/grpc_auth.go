@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/gauss2302/testcommm/product/proto/auth"
+)
+
+// UnaryInterceptor authenticates gRPC calls using the bearer token in the
+// "authorization" metadata and stores the user ID in the context under the
+// same key as the HTTP middleware.
+func (m *AuthMiddleware) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	parts := strings.Split(values[0], " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+	}
+
+	resp, err := m.authClient.VerifyToken(ctx, &pb.VerifyTokenRequest{
+		Token: parts[1],
+	})
+	if err != nil {
+		log.Printf("Error verifying token for %s: %v", info.FullMethod, err)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	return handler(context.WithValue(ctx, "user_id", resp.UserId), req)
+}
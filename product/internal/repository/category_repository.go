@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryExists      = errors.New("category slug already exists")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrCategoryCycle       = errors.New("category cannot be moved below itself")
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// Create inserts the category below its parent, if any, and fills in its path.
+func (r *CategoryRepository) Create(ctx context.Context, category *entity.Category) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		parentPath := "/"
+		if category.ParentID != nil {
+			parent, err := getCategory(tx, *category.ParentID)
+			if err != nil {
+				return err
+			}
+			parentPath = parent.Path
+		}
+
+		if err := tx.Omit(clause.Associations).Create(category).Error; err != nil {
+			return err
+		}
+
+		category.Path = fmt.Sprintf("%s%d/", parentPath, category.ID)
+		return tx.Model(category).Update("path", category.Path).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrCategoryExists
+	}
+	return err
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uint) (*entity.Category, error) {
+	return getCategory(r.db.WithContext(ctx), id)
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*entity.Category, error) {
+	var category entity.Category
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func getCategory(db *gorm.DB, id uint) (*entity.Category, error) {
+	var category entity.Category
+	err := db.First(&category, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// List returns every category, parents before their children.
+func (r *CategoryRepository) List(ctx context.Context) ([]*entity.Category, error) {
+	var categories []*entity.Category
+	if err := r.db.WithContext(ctx).Order("path").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Update renames the category and moves it, along with its subtree, below
+// the given parent. A nil schema leaves the attribute schema unchanged.
+func (r *CategoryRepository) Update(ctx context.Context, id uint, name, slug string, parentID *uint, schema *entity.AttributeSchema) (*entity.Category, error) {
+	var category *entity.Category
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		category, err = getCategory(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		parentPath := "/"
+		if parentID != nil {
+			parent, err := getCategory(tx, *parentID)
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(parent.Path, category.Path) {
+				return ErrCategoryCycle
+			}
+			parentPath = parent.Path
+		}
+
+		oldPath := category.Path
+		category.Name = name
+		category.Slug = slug
+		category.ParentID = parentID
+		category.Path = fmt.Sprintf("%s%d/", parentPath, category.ID)
+
+		updates := map[string]interface{}{
+			"name":      name,
+			"slug":      slug,
+			"parent_id": parentID,
+		}
+		if schema != nil {
+			category.AttributeSchema = *schema
+			updates["attribute_schema"] = *schema
+		}
+		if err := tx.Model(&entity.Category{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if category.Path == oldPath {
+			return nil
+		}
+		// Rewrite the path prefix of the category and all its descendants
+		return tx.Model(&entity.Category{}).
+			Where("path LIKE ?", escapeLike(oldPath)+"%").
+			Update("path", gorm.Expr("? || substr(path, ?)", category.Path, len(oldPath)+1)).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrCategoryExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// Delete removes a category without subcategories. Its product assignments
+// are removed with it.
+func (r *CategoryRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&entity.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+
+		result := tx.Delete(&entity.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCategoryNotFound
+		}
+		return nil
+	})
+}
+
+// ListByProductID returns the categories the product is assigned to.
+func (r *CategoryRepository) ListByProductID(ctx context.Context, productID uint64) ([]*entity.Category, error) {
+	var categories []*entity.Category
+	err := r.db.WithContext(ctx).
+		Joins("JOIN product_categories pc ON pc.category_id = categories.id").
+		Where("pc.product_id = ?", productID).
+		Order("categories.path").
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// ListByIDs returns the categories with the given IDs; unknown IDs are
+// skipped.
+func (r *CategoryRepository) ListByIDs(ctx context.Context, ids []uint) ([]*entity.Category, error) {
+	var categories []*entity.Category
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("path").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Ancestors loads every category on the paths of the given ones, keyed by ID.
+func (r *CategoryRepository) Ancestors(ctx context.Context, categories []*entity.Category) (map[uint]*entity.Category, error) {
+	var ids []uint
+	for _, category := range categories {
+		ids = append(ids, PathIDs(category.Path)...)
+	}
+
+	result := make(map[uint]*entity.Category, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var ancestors []*entity.Category
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&ancestors).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range ancestors {
+		result[category.ID] = category
+	}
+	return result, nil
+}
+
+// SetProductCategories replaces the categories the product is assigned to.
+func (r *CategoryRepository) SetProductCategories(ctx context.Context, productID uint64, categoryIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return setProductCategories(tx, productID, categoryIDs)
+	})
+}
+
+func setProductCategories(tx *gorm.DB, productID uint64, categoryIDs []uint) error {
+	if len(categoryIDs) > 0 {
+		var found int64
+		if err := tx.Model(&entity.Category{}).Where("id IN ?", categoryIDs).Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(categoryIDs) {
+			return ErrCategoryNotFound
+		}
+	}
+
+	if err := tx.Where("product_id = ?", productID).Delete(&entity.ProductCategory{}).Error; err != nil {
+		return err
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]*entity.ProductCategory, len(categoryIDs))
+	for i, id := range categoryIDs {
+		rows[i] = &entity.ProductCategory{ProductID: uint(productID), CategoryID: id}
+	}
+	return tx.Omit(clause.Associations).Create(&rows).Error
+}
+
+// PathIDs returns the category IDs of a path, from the root down.
+func PathIDs(path string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
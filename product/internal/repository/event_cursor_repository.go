@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EventCursorRepository struct {
+	db *gorm.DB
+}
+
+func NewEventCursorRepository(db *gorm.DB) *EventCursorRepository {
+	return &EventCursorRepository{db: db}
+}
+
+// Get returns the stored cursor, or an empty string if none was saved yet.
+func (r *EventCursorRepository) Get(ctx context.Context, name string) (string, error) {
+	var cursor entity.EventCursor
+	err := r.db.WithContext(ctx).First(&cursor, "name = ?", name).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return cursor.Cursor, nil
+}
+
+func (r *EventCursorRepository) Save(ctx context.Context, name, cursor string) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cursor", "updated_at"}),
+	}).Create(&entity.EventCursor{Name: name, Cursor: cursor}).Error
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrImageNotFound = errors.New("image not found")
+	ErrImageLimit    = errors.New("product has too many images")
+)
+
+type ImageRepository struct {
+	db *gorm.DB
+}
+
+func NewImageRepository(db *gorm.DB) *ImageRepository {
+	return &ImageRepository{db: db}
+}
+
+// Create appends the image to the product's images unless it already has
+// limit images. The first image of a product becomes its primary image.
+func (r *ImageRepository) Create(ctx context.Context, image *entity.ProductImage, limit int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialize uploads to the same product so positions stay unique and
+		// concurrent uploads cannot exceed the limit
+		var product entity.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&product, image.ProductID).Error; err != nil {
+			return err
+		}
+
+		var last struct {
+			Count    int64
+			Position int
+		}
+		err := tx.Model(&entity.ProductImage{}).
+			Select("count(*) AS count, coalesce(max(position), -1) AS position").
+			Where("product_id = ?", image.ProductID).
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		if last.Count >= limit {
+			return ErrImageLimit
+		}
+
+		image.Position = last.Position + 1
+		image.IsPrimary = last.Count == 0
+		return tx.Omit(clause.Associations).Create(image).Error
+	})
+}
+
+func (r *ImageRepository) Count(ctx context.Context, productID uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.ProductImage{}).Where("product_id = ?", productID).Count(&count).Error
+	return count, err
+}
+
+// ListByProductIDs returns the images of the given products in display
+// order, primary images first.
+func (r *ImageRepository) ListByProductIDs(ctx context.Context, productIDs []uint) ([]*entity.ProductImage, error) {
+	var images []*entity.ProductImage
+	err := r.db.WithContext(ctx).
+		Where("product_id IN ?", productIDs).
+		Order("product_id, is_primary DESC, position, id").
+		Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// Get returns an image of the product.
+func (r *ImageRepository) Get(ctx context.Context, productID, id uint64) (*entity.ProductImage, error) {
+	var image entity.ProductImage
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).First(&image, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// Delete removes an image of the product and returns it. When it was the
+// primary image, the first remaining image takes its place.
+func (r *ImageRepository) Delete(ctx context.Context, productID, id uint64) (*entity.ProductImage, error) {
+	var image entity.ProductImage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Returning{}).Where("product_id = ? AND id = ?", productID, id).Delete(&image).Error
+		if err != nil {
+			return err
+		}
+		if image.ID == 0 {
+			return ErrImageNotFound
+		}
+		if !image.IsPrimary {
+			return nil
+		}
+
+		var next entity.ProductImage
+		err = tx.Where("product_id = ?", productID).Order("position, id").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// Reorder sets the positions of the product's images to their index in ids.
+func (r *ImageRepository) Reorder(ctx context.Context, productID uint64, ids []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for position, id := range ids {
+			err := tx.Model(&entity.ProductImage{}).
+				Where("product_id = ? AND id = ?", productID, id).
+				Update("position", position).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetPrimary makes the image the primary image of its product.
+func (r *ImageRepository) SetPrimary(ctx context.Context, productID, id uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.ProductImage{}).
+			Where("product_id = ? AND is_primary", productID).
+			Update("is_primary", false).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&entity.ProductImage{}).
+			Where("product_id = ? AND id = ?", productID, id).
+			Update("is_primary", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrImageNotFound
+		}
+		return nil
+	})
+}
+
+// ListByUserID returns the images of every product the user owns personally,
+// including products in the trash, matching DeleteByUserID.
+func (r *ImageRepository) ListByUserID(ctx context.Context, userID uint64) ([]*entity.ProductImage, error) {
+	var images []*entity.ProductImage
+	err := r.db.WithContext(ctx).
+		Joins("JOIN products ON products.id = product_images.product_id").
+		Where("products.user_id = ? AND products.org_id IS NULL", userID).
+		Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationClosed   = errors.New("reservation is no longer pending")
+	ErrReservationExpired  = errors.New("reservation has expired")
+)
+
+// InventoryRepository keeps stock levels and their ledger. Every change locks
+// the inventory item row, so concurrent reservations cannot oversell it.
+// Reservations are always locked before the item they hold stock of.
+type InventoryRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// ListByProductID returns the stock of the product and its variants, leaving
+// out the items of deleted variants.
+func (r *InventoryRepository) ListByProductID(ctx context.Context, productID uint64) ([]*entity.InventoryItem, error) {
+	var items []*entity.InventoryItem
+	err := r.db.WithContext(ctx).
+		Where("product_id = ? AND archived_at IS NULL", productID).
+		Order("variant_id").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// InventoryChange describes a manual stock change. Delta is added to the
+// stock on hand unless Set is given, which replaces it.
+type InventoryChange struct {
+	ProductID uint64
+	VariantID uint64
+	Delta     int
+	Set       *int
+	ActorID   uint64
+	Note      string
+}
+
+// delta returns the change to the stock on hand of the locked item. The
+// stock cannot drop below what is reserved.
+func (c InventoryChange) delta(item *entity.InventoryItem) (int, error) {
+	delta := c.Delta
+	if c.Set != nil {
+		delta = *c.Set - item.OnHand
+	}
+	if item.OnHand+delta < item.Reserved {
+		return 0, fmt.Errorf("%w: %d on hand, %d reserved", ErrInsufficientStock, item.OnHand, item.Reserved)
+	}
+	return delta, nil
+}
+
+// Adjust changes the stock on hand and records it in the ledger. The stock
+// cannot drop below what is reserved.
+func (r *InventoryRepository) Adjust(ctx context.Context, change InventoryChange) (*entity.InventoryItem, error) {
+	var item entity.InventoryItem
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Items are created on their first adjustment
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&entity.InventoryItem{
+			ProductID: uint(change.ProductID),
+			VariantID: uint(change.VariantID),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := lockItem(tx, &item, "product_id = ? AND variant_id = ?", change.ProductID, change.VariantID); err != nil {
+			return err
+		}
+
+		delta, err := change.delta(&item)
+		if err != nil || delta == 0 {
+			return err
+		}
+
+		return applyChange(tx, &item, &entity.InventoryEntry{
+			OnHandDelta: delta,
+			Reason:      entity.InventoryAdjustment,
+			ActorID:     change.ActorID,
+			Note:        change.Note,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Reserve holds quantity units of the item until the reservation is
+// committed, released or expires.
+func (r *InventoryRepository) Reserve(ctx context.Context, reservation *entity.Reservation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var item entity.InventoryItem
+		err := lockItem(tx, &item, "product_id = ? AND variant_id = ?", reservation.ProductID, reservation.VariantID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: 0 available", ErrInsufficientStock)
+		}
+		if err != nil {
+			return err
+		}
+		if item.Available < reservation.Quantity {
+			return fmt.Errorf("%w: %d available", ErrInsufficientStock, item.Available)
+		}
+
+		reservation.ItemID = item.ID
+		reservation.Status = entity.ReservationPending
+		if err := tx.Omit(clause.Associations).Create(reservation).Error; err != nil {
+			return err
+		}
+
+		return applyChange(tx, &item, &entity.InventoryEntry{
+			ReservedDelta: reservation.Quantity,
+			Reason:        entity.InventoryReservation,
+			ReservationID: &reservation.ID,
+			ActorID:       reservation.UserID,
+		})
+	})
+}
+
+func (r *InventoryRepository) GetReservation(ctx context.Context, id uint64) (*entity.Reservation, error) {
+	var reservation entity.Reservation
+	err := r.db.WithContext(ctx).First(&reservation, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Commit turns a pending reservation into a sale, taking its units off the
+// stock on hand.
+func (r *InventoryRepository) Commit(ctx context.Context, id, actorID uint64, now time.Time) (*entity.Reservation, error) {
+	return r.close(ctx, id, actorID, func(reservation *entity.Reservation) (string, error) {
+		if reservation.Expired(now) {
+			return "", ErrReservationExpired
+		}
+		return entity.ReservationCommitted, nil
+	})
+}
+
+// Release cancels a pending reservation, making its units available again.
+func (r *InventoryRepository) Release(ctx context.Context, id, actorID uint64) (*entity.Reservation, error) {
+	return r.close(ctx, id, actorID, func(*entity.Reservation) (string, error) {
+		return entity.ReservationReleased, nil
+	})
+}
+
+// close moves a pending reservation to the status returned by decide.
+func (r *InventoryRepository) close(ctx context.Context, id, actorID uint64, decide func(*entity.Reservation) (string, error)) (*entity.Reservation, error) {
+	var reservation entity.Reservation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrReservationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if reservation.Status != entity.ReservationPending {
+			return fmt.Errorf("%w: %s", ErrReservationClosed, reservation.Status)
+		}
+
+		status, err := decide(&reservation)
+		if err != nil {
+			return err
+		}
+		return closeReservation(tx, &reservation, status, actorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ExpireReservations releases up to limit pending reservations that expired
+// before now and returns how many it released. Rows locked by another
+// replica are skipped, so sweepers can run everywhere at once.
+func (r *InventoryRepository) ExpireReservations(ctx context.Context, now time.Time, limit int) (int, error) {
+	var expired []*entity.Reservation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", entity.ReservationPending, now).
+			Order("item_id, id").
+			Limit(limit).
+			Find(&expired).Error
+		if err != nil {
+			return err
+		}
+
+		for _, reservation := range expired {
+			if err := closeReservation(tx, reservation, entity.ReservationExpired, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(expired), nil
+}
+
+// closeReservation gives back the reserved units of a locked reservation and,
+// when it is committed, takes them off the stock on hand.
+func closeReservation(tx *gorm.DB, reservation *entity.Reservation, status string, actorID uint64) error {
+	var item entity.InventoryItem
+	if err := lockItem(tx, &item, "id = ?", reservation.ItemID); err != nil {
+		return err
+	}
+
+	if err := applyChange(tx, &item, closingEntry(reservation, status, actorID)); err != nil {
+		return err
+	}
+
+	reservation.Status = status
+	return tx.Model(reservation).Update("status", status).Error
+}
+
+// closingEntry is the ledger entry of a reservation closing with status.
+func closingEntry(reservation *entity.Reservation, status string, actorID uint64) *entity.InventoryEntry {
+	entry := &entity.InventoryEntry{
+		ReservedDelta: -reservation.Quantity,
+		ReservationID: &reservation.ID,
+		ActorID:       actorID,
+	}
+	switch status {
+	case entity.ReservationCommitted:
+		entry.OnHandDelta = -reservation.Quantity
+		entry.Reason = entity.InventoryCommit
+	case entity.ReservationExpired:
+		entry.Reason = entity.InventoryExpiry
+	default:
+		entry.Reason = entity.InventoryRelease
+	}
+	return entry
+}
+
+func lockItem(tx *gorm.DB, item *entity.InventoryItem, query string, args ...interface{}) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(item).Error
+}
+
+// applyChange updates a locked item by the entry's deltas and records the
+// entry in the ledger.
+func applyChange(tx *gorm.DB, item *entity.InventoryItem, entry *entity.InventoryEntry) error {
+	item.OnHand += entry.OnHandDelta
+	item.Reserved += entry.ReservedDelta
+	item.Available = item.OnHand - item.Reserved
+
+	err := tx.Model(item).Updates(map[string]interface{}{
+		"on_hand":  item.OnHand,
+		"reserved": item.Reserved,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	entry.ItemID = item.ID
+	entry.ProductID = item.ProductID
+	entry.VariantID = item.VariantID
+	return tx.Omit(clause.Associations).Create(entry).Error
+}
+
+// Ledger returns the ledger entries of the product, newest first, optionally
+// limited to one variant, along with their total count.
+func (r *InventoryRepository) Ledger(ctx context.Context, productID uint64, variantID *uint64, offset, limit int) ([]*entity.InventoryEntry, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.InventoryEntry{}).Where("product_id = ?", productID)
+	if variantID != nil {
+		query = query.Where("variant_id = ?", *variantID)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []*entity.InventoryEntry
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
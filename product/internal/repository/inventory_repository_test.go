@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+)
+
+func TestInventoryChangeDelta(t *testing.T) {
+	set := func(n int) *int { return &n }
+	item := &entity.InventoryItem{OnHand: 10, Reserved: 4}
+
+	tests := []struct {
+		name    string
+		change  InventoryChange
+		want    int
+		wantErr error
+	}{
+		{"add", InventoryChange{Delta: 5}, 5, nil},
+		{"remove unreserved stock", InventoryChange{Delta: -6}, -6, nil},
+		{"remove reserved stock", InventoryChange{Delta: -7}, 0, ErrInsufficientStock},
+		{"set higher", InventoryChange{Set: set(15)}, 5, nil},
+		{"set to reserved", InventoryChange{Set: set(4)}, -6, nil},
+		{"set below reserved", InventoryChange{Set: set(3)}, 0, ErrInsufficientStock},
+		{"set unchanged", InventoryChange{Set: set(10)}, 0, nil},
+		{"set overrides delta", InventoryChange{Delta: 100, Set: set(12)}, 2, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.change.delta(item)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: delta error = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: delta = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClosingEntry(t *testing.T) {
+	reservation := &entity.Reservation{ID: 3, Quantity: 2}
+
+	tests := []struct {
+		status   string
+		onHand   int
+		reserved int
+		reason   string
+	}{
+		{entity.ReservationCommitted, -2, -2, entity.InventoryCommit},
+		{entity.ReservationReleased, 0, -2, entity.InventoryRelease},
+		{entity.ReservationExpired, 0, -2, entity.InventoryExpiry},
+	}
+
+	for _, tt := range tests {
+		entry := closingEntry(reservation, tt.status, 7)
+		if entry.OnHandDelta != tt.onHand || entry.ReservedDelta != tt.reserved || entry.Reason != tt.reason {
+			t.Errorf("closingEntry(%s) = on hand %d, reserved %d, %s; want %d, %d, %s",
+				tt.status, entry.OnHandDelta, entry.ReservedDelta, entry.Reason, tt.onHand, tt.reserved, tt.reason)
+		}
+		if entry.ReservationID == nil || *entry.ReservationID != reservation.ID || entry.ActorID != 7 {
+			t.Errorf("closingEntry(%s) does not refer to reservation %d by actor 7", tt.status, reservation.ID)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"gorm.io/gorm"
+)
+
+// LeaderLock elects one replica to run background work by holding a
+// session-level Postgres advisory lock on a dedicated connection. The lock is
+// released when the connection closes, so a replica that dies or loses its
+// connection hands the leadership over to another one.
+type LeaderLock struct {
+	db   *gorm.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func NewLeaderLock(db *gorm.DB, key int64) *LeaderLock {
+	return &LeaderLock{db: db, key: key}
+}
+
+// Acquire reports whether this replica leads, taking the lock if it is free.
+// It is not safe for concurrent use.
+func (l *LeaderLock) Acquire(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		// The lock went away with the connection
+		l.Release()
+	}
+
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&locked); err != nil {
+		conn.Close()
+		return false, err
+	}
+	if !locked {
+		conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Release gives up the leadership.
+func (l *LeaderLock) Release() {
+	if l.conn == nil {
+		return
+	}
+	if _, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		// Discard the connection instead of returning it to the pool with
+		// the lock still held
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	l.conn.Close()
+	l.conn = nil
+}
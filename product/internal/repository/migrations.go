@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// migrationLockKey serializes migrations between instances starting at the
+// same time.
+const migrationLockKey = 0x6d696772
+
+// MigratePrices converts the legacy floating point price column into exact
+// minor units. Legacy prices were all in US dollars. It runs before
+// AutoMigrate and does nothing once the legacy column is gone.
+func MigratePrices(db *gorm.DB) error {
+	if !db.Migrator().HasTable("products") || !db.Migrator().HasColumn("products", "price") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock(?)`, migrationLockKey).Error; err != nil {
+			return err
+		}
+		// Another instance may have finished while we waited for the lock
+		if !tx.Migrator().HasColumn("products", "price") {
+			return nil
+		}
+
+		for _, stmt := range []string{
+			`ALTER TABLE products ADD COLUMN IF NOT EXISTS price_minor bigint`,
+			`ALTER TABLE products ADD COLUMN IF NOT EXISTS currency varchar(3) NOT NULL DEFAULT 'USD'`,
+			`UPDATE products SET price_minor = round(price::numeric * 100) WHERE price_minor IS NULL`,
+			`ALTER TABLE products ALTER COLUMN price_minor SET NOT NULL`,
+			`ALTER TABLE products DROP COLUMN price`,
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MigrateProductStatus adds the status column, publishing every product that
+// existed before products had a lifecycle. It runs before AutoMigrate and
+// does nothing once the column exists.
+func MigrateProductStatus(db *gorm.DB) error {
+	if !db.Migrator().HasTable("products") || db.Migrator().HasColumn("products", "status") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock(?)`, migrationLockKey).Error; err != nil {
+			return err
+		}
+		// Another instance may have finished while we waited for the lock
+		if tx.Migrator().HasColumn("products", "status") {
+			return nil
+		}
+
+		for _, stmt := range []string{
+			`ALTER TABLE products ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'published'`,
+			`ALTER TABLE products ADD COLUMN IF NOT EXISTS published_at timestamptz`,
+			`UPDATE products SET published_at = created_at`,
+			`ALTER TABLE products ALTER COLUMN status SET DEFAULT 'draft'`,
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
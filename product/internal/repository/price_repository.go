@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrPromotionOverlap  = errors.New("promotion overlaps another promotion of the product")
+)
+
+// PriceRepository keeps the price history and promotions of products.
+// Promotions enter the price history when they start and end, so it shows
+// the prices buyers paid.
+type PriceRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceRepository(db *gorm.DB) *PriceRepository {
+	return &PriceRepository{db: db}
+}
+
+// History returns the price changes of the product, newest first, along with
+// their total count.
+func (r *PriceRepository) History(ctx context.Context, productID uint64, offset, limit int) ([]*entity.PriceChange, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.PriceChange{}).Where("product_id = ?", productID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var changes []*entity.PriceChange
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
+
+// CreatePromotion adds a promotion unless it overlaps one of the product's
+// other promotions. The product row is locked so that concurrent promotions
+// cannot overlap either.
+func (r *PriceRepository) CreatePromotion(ctx context.Context, promotion *entity.Promotion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product entity.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			First(&product, promotion.ProductID).Error
+		if err != nil {
+			return err
+		}
+
+		var later []*entity.Promotion
+		err = tx.Where("product_id = ? AND ends_at > ?", promotion.ProductID, promotion.StartsAt).
+			Find(&later).Error
+		if err != nil {
+			return err
+		}
+		for _, other := range later {
+			if promotion.Overlaps(other) {
+				return ErrPromotionOverlap
+			}
+		}
+		return tx.Create(promotion).Error
+	})
+}
+
+// ListPromotions returns the promotions of the product that have not ended
+// by now, in the order they start.
+func (r *PriceRepository) ListPromotions(ctx context.Context, productID uint64, now time.Time) ([]*entity.Promotion, error) {
+	var promotions []*entity.Promotion
+	err := r.db.WithContext(ctx).
+		Where("product_id = ? AND ends_at > ?", productID, now).
+		Order("starts_at").
+		Find(&promotions).Error
+	if err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// DeletePromotion cancels a promotion of the product. A running promotion
+// ends early, which is recorded in the price history on behalf of the actor.
+func (r *PriceRepository) DeletePromotion(ctx context.Context, productID, id, actorID uint64, now time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var promotion entity.Promotion
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ?", productID).
+			First(&promotion, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPromotionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := recordPromotion(tx, &promotion, now); err != nil {
+			return err
+		}
+		if promotion.StartRecorded && !promotion.EndRecorded {
+			if err := endPromotion(tx, &promotion, now, entity.PricePromotionCancelled, actorID); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&promotion).Error
+	})
+}
+
+// RecordPromotions records up to limit promotion starts and ends that are
+// due by now in the price history, and returns how many promotions it
+// updated. Rows locked by another transaction are skipped.
+func (r *PriceRepository) RecordPromotions(ctx context.Context, now time.Time, limit int) (int, error) {
+	var promotions []*entity.Promotion
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(NOT start_recorded AND starts_at <= ?) OR (NOT end_recorded AND ends_at <= ?)", now, now).
+			Order("id").
+			Limit(limit).
+			Find(&promotions).Error
+		if err != nil {
+			return err
+		}
+
+		for _, promotion := range promotions {
+			if err := recordPromotion(tx, promotion, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(promotions), nil
+}
+
+// recordPromotion records the start and the end of a locked promotion in the
+// price history once they are due by now. Entries carry the time the
+// promotion started or ended, not the time they were recorded.
+func recordPromotion(tx *gorm.DB, promotion *entity.Promotion, now time.Time) error {
+	if !promotion.StartRecorded && !promotion.StartsAt.After(now) {
+		product, err := promotedProduct(tx, promotion)
+		if err != nil {
+			return err
+		}
+		promotion.StartRecorded = true
+		if promotion.Applies(product) {
+			err = tx.Create(&entity.PriceChange{
+				ProductID:     promotion.ProductID,
+				OldPriceMinor: &product.PriceMinor,
+				OldCurrency:   &product.Currency,
+				PriceMinor:    promotion.PriceMinor,
+				Currency:      promotion.Currency,
+				Reason:        entity.PricePromotionStarted,
+				PromotionID:   &promotion.ID,
+				ActorID:       promotion.CreatedBy,
+				CreatedAt:     promotion.StartsAt,
+			}).Error
+			if err != nil {
+				return err
+			}
+		} else {
+			promotion.EndRecorded = true
+		}
+	}
+
+	if promotion.StartRecorded && !promotion.EndRecorded && !promotion.EndsAt.After(now) {
+		if err := endPromotion(tx, promotion, promotion.EndsAt, entity.PricePromotionEnded, promotion.CreatedBy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(promotion).Updates(map[string]interface{}{
+		"start_recorded": promotion.StartRecorded,
+		"end_recorded":   promotion.EndRecorded,
+	}).Error
+}
+
+// endPromotion records that the sale price of a locked promotion gave way to
+// the product's price at the given time.
+func endPromotion(tx *gorm.DB, promotion *entity.Promotion, at time.Time, reason string, actorID uint64) error {
+	product, err := promotedProduct(tx, promotion)
+	if err != nil {
+		return err
+	}
+	promotion.EndRecorded = true
+	return tx.Create(&entity.PriceChange{
+		ProductID:     promotion.ProductID,
+		OldPriceMinor: &promotion.PriceMinor,
+		OldCurrency:   &promotion.Currency,
+		PriceMinor:    product.PriceMinor,
+		Currency:      product.Currency,
+		Reason:        reason,
+		PromotionID:   &promotion.ID,
+		ActorID:       actorID,
+		CreatedAt:     at,
+	}).Error
+}
+
+// promotedProduct returns the price of the promotion's product, which may be
+// in the trash.
+func promotedProduct(tx *gorm.DB, promotion *entity.Promotion) (*entity.Product, error) {
+	var product entity.Product
+	err := tx.Unscoped().
+		Select("id", "price_minor", "currency").
+		First(&product, promotion.ProductID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// ActivePromotions returns the promotions of the products running at now.
+// Promotions of a product never overlap, so there is at most one each.
+func (r *PriceRepository) ActivePromotions(ctx context.Context, productIDs []uint, now time.Time) ([]*entity.Promotion, error) {
+	var promotions []*entity.Promotion
+	err := r.db.WithContext(ctx).
+		Where("product_id IN ? AND starts_at <= ? AND ends_at > ?", productIDs, now, now).
+		Find(&promotions).Error
+	if err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
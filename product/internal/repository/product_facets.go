@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+)
+
+// PriceBucketBounds are the upper bounds of the price facet buckets in major
+// units; the last bucket is open-ended.
+var PriceBucketBounds = []float64{10, 50, 100, 500, 1000}
+
+const maxTagFacets = 50
+
+// Facets summarizes all products matching a filter, for rendering filters.
+type Facets struct {
+	Tags       []TagCount      `json:"tags"`
+	Categories []CategoryCount `json:"categories"`
+	// Prices only counts products priced in PriceCurrency.
+	Prices        []PriceBucket `json:"prices"`
+	PriceCurrency string        `json:"price_currency"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// CategoryCount counts the products in a category and its descendants.
+type CategoryCount struct {
+	ID       uint   `json:"id"`
+	ParentID *uint  `json:"parent_id"`
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Count    int64  `json:"count"`
+}
+
+// PriceBucket counts products with Min <= price < Max. Max is nil for the
+// last bucket.
+type PriceBucket struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
+}
+
+// Facets aggregates tag, category and price counts over every product
+// matching the filter. Prices are bucketed in the given currency. Empty
+// buckets are left out.
+func (r *ProductRepository) Facets(ctx context.Context, filter ProductFilter, currency string) (*Facets, error) {
+	facets := &Facets{
+		Tags:          []TagCount{},
+		Categories:    []CategoryCount{},
+		Prices:        []PriceBucket{},
+		PriceCurrency: currency,
+	}
+	matching := r.filtered(ctx, filter).Select("products.id")
+
+	err := r.db.WithContext(ctx).Table("product_tags pt").
+		Select("t.name AS tag, count(*) AS count").
+		Joins("JOIN tags t ON t.id = pt.tag_id").
+		Where("pt.product_id IN (?)", matching).
+		Group("t.name").
+		Order("count DESC, t.name").
+		Limit(maxTagFacets).
+		Scan(&facets.Tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Products count towards their categories and all of their ancestors
+	err = r.db.WithContext(ctx).Table("product_categories pc").
+		Select("anc.id, anc.parent_id, anc.slug, anc.name, count(DISTINCT pc.product_id) AS count").
+		Joins("JOIN categories c ON c.id = pc.category_id").
+		Joins("JOIN categories anc ON c.path LIKE anc.path || '%'").
+		Where("pc.product_id IN (?)", matching).
+		Group("anc.id, anc.parent_id, anc.slug, anc.name, anc.path").
+		Order("anc.path").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []struct {
+		Bucket int
+		Count  int64
+	}
+	err = r.filtered(ctx, filter).
+		Where("currency = ?", currency).
+		Select(priceBucketExpr(currency) + " AS bucket, count(*) AS count").
+		Group("bucket").
+		Order("bucket").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range buckets {
+		bucket := PriceBucket{Count: b.Count}
+		if b.Bucket > 0 {
+			bucket.Min = PriceBucketBounds[b.Bucket-1]
+		}
+		if b.Bucket < len(PriceBucketBounds) {
+			max := PriceBucketBounds[b.Bucket]
+			bucket.Max = &max
+		}
+		facets.Prices = append(facets.Prices, bucket)
+	}
+
+	return facets, nil
+}
+
+// priceBucketExpr numbers the price buckets from 0 in SQL by the effective
+// price: width_bucket counts the bounds at or below it.
+func priceBucketExpr(currency string) string {
+	scale := math.Pow10(money.Exponent(currency))
+
+	bounds := make([]string, len(PriceBucketBounds))
+	for i, bound := range PriceBucketBounds {
+		bounds[i] = strconv.FormatInt(int64(bound*scale), 10)
+	}
+	return fmt.Sprintf("width_bucket(%s, ARRAY[%s]::bigint[])", effectivePrice, strings.Join(bounds, ", "))
+}
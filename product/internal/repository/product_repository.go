@@ -2,9 +2,14 @@ package repository
 
 import (
 	"context"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
@@ -15,8 +20,33 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (r *ProductRepository) Create(ctx context.Context, product *entity.Product) error {
-	return r.db.WithContext(ctx).Create(product).Error
+// Create stores a new product in the given categories.
+func (r *ProductRepository) Create(ctx context.Context, product *entity.Product, categoryIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if len(categoryIDs) > 0 {
+			if err := setProductCategories(tx, uint64(product.ID), categoryIDs); err != nil {
+				return err
+			}
+		}
+		err := tx.Create(&entity.PriceChange{
+			ProductID:  product.ID,
+			PriceMinor: product.PriceMinor,
+			Currency:   product.Currency,
+			Reason:     entity.PriceCreated,
+			ActorID:    product.UserID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if product.Tags == nil {
+			product.Tags = []string{}
+			return nil
+		}
+		return setTags(tx, uint64(product.ID), product.Tags)
+	})
 }
 
 func (r *ProductRepository) GetByID(ctx context.Context, id uint64) (*entity.Product, error) {
@@ -27,28 +57,521 @@ func (r *ProductRepository) GetByID(ctx context.Context, id uint64) (*entity.Pro
 	return &product, nil
 }
 
-func (r *ProductRepository) List(ctx context.Context, page, perPage int32) ([]*entity.Product, int64, error) {
-	var products []*entity.Product
-	var total int64
+// ProductFilter selects products for listings. Zero values disable the
+// corresponding condition.
+type ProductFilter struct {
+	// Query is matched against name and description with Postgres full-text
+	// search.
+	Query string
+	// MinPrice and MaxPrice bound the effective price, with any running
+	// promotion applied, in minor units of Currency.
+	MinPrice int64
+	MaxPrice int64
+	// Currency restricts the listing to prices in that currency.
+	Currency      string
+	OwnerID       uint64
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// Category is a category slug; it matches products in the category and
+	// all its descendants. The service resolves it to CategoryPath.
+	Category     string
+	CategoryPath string
+	// Tags matches products with any of the tags, or all of them with
+	// MatchAllTags.
+	Tags         []string
+	MatchAllTags bool
+	// Attributes matches products whose attribute equals one of the given
+	// values, compared as text, for every listed attribute.
+	Attributes map[string][]string
+	// Status restricts the listing to products in that status.
+	Status string
+	// Deleted lists the products in the trash instead of live ones.
+	Deleted bool
+	// ManagedBy matches the products the user owns personally, together
+	// with every product of ManagedOrgs.
+	ManagedBy   uint64
+	ManagedOrgs []uint64
+	// IncludeHidden also lists products of hidden owners, for owners viewing
+	// their own products.
+	IncludeHidden bool
+	// SortBy must be one of the columns in ProductSortColumns.
+	SortBy     string
+	Descending bool
+}
 
-	if err := r.db.WithContext(ctx).Model(&entity.Product{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+// ProductSortColumns whitelists the fields listings can be sorted by.
+// "relevance" only applies together with a query. Prices sort by their
+// effective amount regardless of currency.
+var ProductSortColumns = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"price":      true,
+	"created_at": true,
+	"relevance":  true,
+}
+
+// sortColumns maps sort fields to the columns backing them.
+var sortColumns = map[string]string{
+	"name":       "name",
+	"price":      effectivePrice,
+	"created_at": "created_at",
+}
+
+// effectivePrice is the price of a product with its running promotion
+// applied, as loadPromotions applies it.
+const effectivePrice = `COALESCE((
+	SELECT pr.price_minor FROM promotions pr
+	WHERE pr.product_id = products.id AND pr.deleted_at IS NULL
+		AND pr.currency = products.currency AND pr.price_minor < products.price_minor
+		AND pr.starts_at <= now() AND pr.ends_at > now()
+	LIMIT 1
+), products.price_minor)`
+
+// ProductPage selects a page of results, either by offset or, when Cursor is
+// set, by keyset relative to the cursor row.
+type ProductPage struct {
+	Offset int
+	Limit  int
+	Cursor *ProductCursor
+	// Backward returns the rows preceding the cursor instead of following it.
+	Backward  bool
+	SkipCount bool
+}
+
+// ProductCursor identifies a row by its sort value and ID.
+type ProductCursor struct {
+	Value string
+	ID    uint
+}
+
+const searchQuery = "websearch_to_tsquery('english', ?)"
+
+// List returns the matching products in sort order along with their total
+// count, which is -1 when page.SkipCount is set.
+func (r *ProductRepository) List(ctx context.Context, filter ProductFilter, page ProductPage) ([]*entity.Product, int64, error) {
+	query := r.filtered(ctx, filter)
+
+	total := int64(-1)
+	if !page.SkipCount {
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	sortExpr, sortVars := "", []interface{}(nil)
+	switch {
+	case filter.SortBy == "relevance" && filter.Query != "":
+		sortExpr, sortVars = "ts_rank(search_vector, "+searchQuery+")", []interface{}{filter.Query}
+		query = query.Select("*, "+sortExpr+" AS rank", filter.Query)
+	case sortColumns[filter.SortBy] != "":
+		sortExpr = sortColumns[filter.SortBy]
+		if filter.SortBy == "price" {
+			query = query.Select("*, " + sortExpr + " AS effective_price_minor")
+		}
+	}
+
+	// Walking backward reverses the order; the rows are flipped back below
+	descending := filter.Descending != page.Backward
+	direction, cmp := "ASC", ">"
+	if descending {
+		direction, cmp = "DESC", "<"
+	}
+
+	if page.Cursor != nil {
+		if sortExpr == "" {
+			query = query.Where("id "+cmp+" ?", page.Cursor.ID)
+		} else {
+			value, err := cursorValue(filter.SortBy, page.Cursor.Value)
+			if err != nil {
+				return nil, 0, err
+			}
+			vars := append(append([]interface{}{}, sortVars...), value, page.Cursor.ID)
+			query = query.Where("("+sortExpr+", id) "+cmp+" (?, ?)", vars...)
+		}
+	} else if page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+
+	if sortExpr != "" {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  sortExpr + " " + direction,
+			Vars: sortVars,
+		}})
 	}
+	query = query.Order("id " + direction)
 
-	offset := (page - 1) * perPage
-	if err := r.db.WithContext(ctx).Offset(int(offset)).Limit(int(perPage)).Find(&products).Error; err != nil {
+	var products []*entity.Product
+	if err := query.Limit(page.Limit).Find(&products).Error; err != nil {
 		return nil, 0, err
 	}
 
+	if page.Backward {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+	}
+
 	return products, total, nil
 }
 
-func (r *ProductRepository) Update(ctx context.Context, id uint64, product *entity.Product) error {
-	return r.db.WithContext(ctx).Model(&entity.Product{}).Where("id = ?", id).Updates(product).Error
+// filtered returns a query over the products matching the filter.
+func (r *ProductRepository) filtered(ctx context.Context, filter ProductFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&entity.Product{})
+
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if !filter.IncludeHidden {
+		query = query.Where("owner_hidden = ?", false)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Query != "" {
+		query = query.Where("search_vector @@ "+searchQuery, filter.Query)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.MinPrice > 0 {
+		query = query.Where(effectivePrice+" >= ?", filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		query = query.Where(effectivePrice+" <= ?", filter.MaxPrice)
+	}
+	if filter.OwnerID != 0 {
+		query = query.Where("user_id = ?", filter.OwnerID)
+	}
+	if filter.ManagedBy != 0 {
+		if len(filter.ManagedOrgs) > 0 {
+			query = query.Where("((user_id = ? AND org_id IS NULL) OR org_id IN ?)", filter.ManagedBy, filter.ManagedOrgs)
+		} else {
+			query = query.Where("user_id = ? AND org_id IS NULL", filter.ManagedBy)
+		}
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if len(filter.Tags) > 0 {
+		tagged := r.db.Table("product_tags pt").
+			Joins("JOIN tags t ON t.id = pt.tag_id").
+			Where("pt.product_id = products.id AND t.name IN ?", filter.Tags)
+		if filter.MatchAllTags {
+			query = query.Where("(?) = ?", tagged.Select("count(DISTINCT t.id)"), len(filter.Tags))
+		} else {
+			query = query.Where("EXISTS (?)", tagged.Select("1"))
+		}
+	}
+	if len(filter.Attributes) > 0 {
+		names := make([]string, 0, len(filter.Attributes))
+		for name := range filter.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			query = query.Where("attributes->>? IN ?", name, filter.Attributes[name])
+		}
+	}
+	if filter.CategoryPath != "" {
+		query = query.Where(`EXISTS (
+			SELECT 1 FROM product_categories pc
+			JOIN categories c ON c.id = pc.category_id
+			WHERE pc.product_id = products.id AND c.path LIKE ?
+		)`, escapeLike(filter.CategoryPath)+"%")
+	}
+
+	return query
+}
+
+// CursorFor returns the cursor of the product in the given sort order.
+func CursorFor(product *entity.Product, sortBy string) ProductCursor {
+	cursor := ProductCursor{ID: product.ID}
+	switch sortBy {
+	case "name":
+		cursor.Value = product.Name
+	case "price":
+		cursor.Value = strconv.FormatInt(product.EffectivePriceMinor, 10)
+	case "created_at":
+		cursor.Value = product.CreatedAt.Format(time.RFC3339Nano)
+	case "relevance":
+		cursor.Value = strconv.FormatFloat(product.Rank, 'g', -1, 64)
+	}
+	return cursor
+}
+
+func cursorValue(sortBy, value string) (interface{}, error) {
+	switch sortBy {
+	case "price":
+		return strconv.ParseInt(value, 10, 64)
+	case "relevance":
+		return strconv.ParseFloat(value, 64)
+	case "created_at":
+		return time.Parse(time.RFC3339Nano, value)
+	}
+	return value, nil
+}
+
+// Update saves the non-zero fields of product. A new price is recorded in the
+// price history on behalf of the actor. With resubmit, a published product
+// goes back to review along with the change.
+func (r *ProductRepository) Update(ctx context.Context, id uint64, product *entity.Product, actorID uint64, resubmit bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if resubmit {
+			err := tx.Model(&entity.Product{}).
+				Where("id = ? AND status = ?", id, entity.StatusPublished).
+				Update("status", entity.StatusPendingReview).Error
+			if err != nil {
+				return err
+			}
+		}
+		if product.PriceMinor != 0 {
+			if _, err := changePrice(tx, id, product.Money(), entity.PriceUpdated, actorID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&entity.Product{}).Where("id = ?", id).Updates(product).Error; err != nil {
+			return err
+		}
+		if product.Tags == nil {
+			return nil
+		}
+		return setTags(tx, id, product.Tags)
+	})
+}
+
+// changePrice sets the price of a product and records the change, unless the
+// price stays the same, and returns the price it replaced. The product row
+// stays locked until tx ends, so concurrent changes are recorded in order.
+// The currency only changes while no variant overrides the price.
+func changePrice(tx *gorm.DB, id uint64, price money.Money, reason string, actorID uint64) (money.Money, error) {
+	var current entity.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "price_minor", "currency").
+		First(&current, id).Error
+	if err != nil {
+		return money.Money{}, err
+	}
+	previous := current.Money()
+	if current.PriceMinor == price.Amount && current.Currency == price.Currency {
+		return previous, nil
+	}
+	if current.Currency != price.Currency {
+		var overrides int64
+		err := tx.Model(&entity.Variant{}).
+			Where("product_id = ? AND price_override_minor IS NOT NULL", id).
+			Count(&overrides).Error
+		if err != nil {
+			return money.Money{}, err
+		}
+		if overrides > 0 {
+			return money.Money{}, ErrPriceOverrides
+		}
+	}
+
+	err = tx.Model(&entity.Product{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"price_minor": price.Amount,
+			"currency":    price.Currency,
+		}).Error
+	if err != nil {
+		return money.Money{}, err
+	}
+	err = tx.Create(&entity.PriceChange{
+		ProductID:     current.ID,
+		OldPriceMinor: &current.PriceMinor,
+		OldCurrency:   &current.Currency,
+		PriceMinor:    price.Amount,
+		Currency:      price.Currency,
+		Reason:        reason,
+		ActorID:       actorID,
+	}).Error
+	return previous, err
 }
 
-func (r *ProductRepository) Delete(ctx context.Context, id uint64) error {
-	return r.db.WithContext(ctx).Delete(&entity.Product{}, id).Error
+// Transition moves the product to status if it is currently in one of from,
+// and reports whether it did. Publishing stamps PublishedAt.
+func (r *ProductRepository) Transition(ctx context.Context, id uint64, from []string, status, note string) (bool, error) {
+	return transition(r.db.WithContext(ctx), id, from, status, note)
+}
+
+func transition(tx *gorm.DB, id uint64, from []string, status, note string) (bool, error) {
+	updates := map[string]interface{}{
+		"status":      status,
+		"review_note": note,
+	}
+	if status == entity.StatusPublished {
+		updates["published_at"] = time.Now()
+	}
+
+	result := tx.Model(&entity.Product{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// setTags replaces the tags of a product, creating tags that do not exist yet.
+func setTags(tx *gorm.DB, productID uint64, names []string) error {
+	if err := tx.Where("product_id = ?", productID).Delete(&entity.ProductTag{}).Error; err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags := make([]*entity.Tag, len(names))
+	for i, name := range names {
+		tags[i] = &entity.Tag{Name: name}
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return err
+	}
+
+	var ids []uint
+	if err := tx.Model(&entity.Tag{}).Where("name IN ?", names).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	rows := make([]*entity.ProductTag, len(ids))
+	for i, id := range ids {
+		rows[i] = &entity.ProductTag{ProductID: uint(productID), TagID: id}
+	}
+	return tx.Omit(clause.Associations).Create(&rows).Error
+}
+
+// LoadTags fills in the tags of the given products.
+func (r *ProductRepository) LoadTags(ctx context.Context, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[uint]*entity.Product, len(products))
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		product.Tags = []string{}
+		byID[product.ID] = product
+		ids[i] = product.ID
+	}
+
+	var rows []struct {
+		ProductID uint
+		Name      string
+	}
+	err := r.db.WithContext(ctx).Table("product_tags pt").
+		Select("pt.product_id, t.name").
+		Joins("JOIN tags t ON t.id = pt.tag_id").
+		Where("pt.product_id IN ?", ids).
+		Order("t.name").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if product, ok := byID[row.ProductID]; ok {
+			product.Tags = append(product.Tags, row.Name)
+		}
+	}
+	return nil
+}
+
+// Delete moves the product to the trash. Its pending reservations are
+// released and its pending scheduled actions cancelled, as neither may run
+// against a deleted product; restoring the product does not bring them back.
+func (r *ProductRepository) Delete(ctx context.Context, id, actorID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&entity.Product{}, id).Error; err != nil {
+			return err
+		}
+
+		var reservations []*entity.Reservation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ? AND status = ?", id, entity.ReservationPending).
+			Order("item_id, id").
+			Find(&reservations).Error
+		if err != nil {
+			return err
+		}
+		for _, reservation := range reservations {
+			if err := closeReservation(tx, reservation, entity.ReservationReleased, actorID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&entity.ScheduledAction{}).
+			Where("product_id = ? AND status = ?", id, entity.SchedulePending).
+			Updates(map[string]interface{}{
+				"status": entity.ScheduleCancelled,
+				"error":  "product deleted",
+			}).Error
+	})
+}
+
+// GetDeletedByID returns a product in the trash.
+func (r *ProductRepository) GetDeletedByID(ctx context.Context, id uint64) (*entity.Product, error) {
+	var product entity.Product
+	if err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&product, id).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Restore takes a product out of the trash.
+func (r *ProductRepository) Restore(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&entity.Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// HardDelete removes a live or trashed product for good, along with
+// everything stored for it.
+func (r *ProductRepository) HardDelete(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).Unscoped().Delete(&entity.Product{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted removes up to limit products that were moved to the trash
+// before the given time for good. It returns the images of the purged
+// products, whose files the caller deletes. Products being purged by another
+// replica are skipped.
+func (r *ProductRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, []*entity.ProductImage, error) {
+	var ids []uint
+	var images []*entity.ProductImage
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&entity.Product{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Order("id").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := tx.Where("product_id IN ?", ids).Find(&images).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&entity.Product{}, ids).Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(ids), images, nil
 }
 
 func (r *ProductRepository) BelongsToUser(ctx context.Context, productID uint64, userID uint64) (bool, error) {
@@ -64,23 +587,21 @@ func (r *ProductRepository) BelongsToUser(ctx context.Context, productID uint64,
 	return count > 0, nil
 }
 
-func (r *ProductRepository) ListByUserID(ctx context.Context, userID uint64, page, perPage int32) ([]*entity.Product, int64, error) {
-	var products []*entity.Product
-	var total int64
-
-	if err := r.db.WithContext(ctx).Model(&entity.Product{}).
-		Where("user_id = ?", userID).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	offset := (page - 1) * perPage
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).
-		Offset(int(offset)).
-		Limit(int(perPage)).
-		Find(&products).Error; err != nil {
-		return nil, 0, err
-	}
+// DeleteByUserID removes every product the user owns personally for good,
+// including those in the trash, and returns how many were deleted. Products
+// the user created for an organization belong to the organization and stay.
+func (r *ProductRepository) DeleteByUserID(ctx context.Context, userID uint64) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("user_id = ? AND org_id IS NULL", userID).
+		Delete(&entity.Product{})
+	return result.RowsAffected, result.Error
+}
 
-	return products, total, nil
+// SetOwnerHidden hides or shows every product the user owns personally in
+// listings, including products in the trash, which may be restored later.
+// Organization products stay visible whatever happens to their creator.
+func (r *ProductRepository) SetOwnerHidden(ctx context.Context, userID uint64, hidden bool) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&entity.Product{}).
+		Where("user_id = ? AND org_id IS NULL", userID).
+		Update("owner_hidden", hidden).Error
 }
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("scheduled action not found")
+	ErrScheduleClosed   = errors.New("scheduled action is no longer pending")
+)
+
+// ScheduleRepository stores the scheduled actions of products.
+type ScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+func (r *ScheduleRepository) Create(ctx context.Context, action *entity.ScheduledAction) error {
+	return r.db.WithContext(ctx).Create(action).Error
+}
+
+// CountPending returns the number of the product's actions yet to run.
+func (r *ScheduleRepository) CountPending(ctx context.Context, productID uint64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.ScheduledAction{}).
+		Where("product_id = ? AND status = ?", productID, entity.SchedulePending).
+		Count(&count).Error
+	return count, err
+}
+
+// ListByProductID returns the product's actions in the order they run,
+// optionally only those in one status.
+func (r *ScheduleRepository) ListByProductID(ctx context.Context, productID uint64, status string) ([]*entity.ScheduledAction, error) {
+	query := r.db.WithContext(ctx).Where("product_id = ?", productID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var actions []*entity.ScheduledAction
+	if err := query.Order("run_at, id").Find(&actions).Error; err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// Cancel stops a pending action of the product from running.
+func (r *ScheduleRepository) Cancel(ctx context.Context, productID, id uint64) (*entity.ScheduledAction, error) {
+	var action entity.ScheduledAction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("product_id = ?", productID).
+			First(&action, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrScheduleNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if action.Status != entity.SchedulePending {
+			return fmt.Errorf("%w: %s", ErrScheduleClosed, action.Status)
+		}
+
+		action.Status = entity.ScheduleCancelled
+		return tx.Model(&action).Update("status", action.Status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// ScheduledChange is what a due action does to its product.
+type ScheduledChange struct {
+	// From and Status move the product to Status if it is in one of From.
+	From   []string
+	Status string
+	// Price replaces the price of the product.
+	Price *money.Money
+}
+
+// RunDue claims the oldest due action, skipping those another transaction
+// holds, and applies the change plan returns for it. The change, the outcome
+// of the action and the revert of a price change commit together, so an
+// action never runs twice, even if the process dies midway. A plan or change
+// that fails is recorded on the action; any other error rolls everything
+// back. RunDue returns nil when no action is due.
+func (r *ScheduleRepository) RunDue(ctx context.Context, now time.Time, plan func(*entity.ScheduledAction) (ScheduledChange, error)) (*entity.ScheduledAction, error) {
+	var action *entity.ScheduledAction
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var due entity.ScheduledAction
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", entity.SchedulePending, now).
+			Order("run_at, id").
+			First(&due).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		followUp, failure, err := applyScheduled(tx, &due, plan)
+		if err != nil {
+			return err
+		}
+		due.Status = entity.ScheduleDone
+		if failure != nil {
+			due.Status = entity.ScheduleFailed
+			due.Error = failure.Error()
+		}
+		due.ExecutedAt = &now
+
+		err = tx.Model(&due).Updates(map[string]interface{}{
+			"status":      due.Status,
+			"error":       due.Error,
+			"executed_at": due.ExecutedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if followUp != nil {
+			if err := tx.Create(followUp).Error; err != nil {
+				return err
+			}
+		}
+		action = &due
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// applyScheduled applies a due action within tx. It returns the revert of a
+// price change that should be undone later, or why the action failed; err is
+// only set when tx can no longer be used.
+func applyScheduled(tx *gorm.DB, action *entity.ScheduledAction, plan func(*entity.ScheduledAction) (ScheduledChange, error)) (followUp *entity.ScheduledAction, failure, err error) {
+	change, failure := plan(action)
+	if failure != nil {
+		return nil, failure, nil
+	}
+	productID := uint64(action.ProductID)
+
+	if change.Status != "" {
+		ok, err := transition(tx, productID, change.From, change.Status, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("product is not %s", strings.Join(change.From, " or ")), nil
+		}
+	}
+
+	if change.Price != nil {
+		previous, err := changePrice(tx, productID, *change.Price, entity.PriceScheduled, action.CreatedBy)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrPriceOverrides) {
+			return nil, err, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if action.RevertAt != nil {
+			followUp = &entity.ScheduledAction{
+				ProductID:  action.ProductID,
+				Action:     entity.SchedulePriceRevert,
+				PriceMinor: &previous.Amount,
+				Currency:   previous.Currency,
+				RunAt:      *action.RevertAt,
+				RevertOf:   &action.ID,
+				Status:     entity.SchedulePending,
+				CreatedBy:  action.CreatedBy,
+			}
+		}
+	}
+	return followUp, nil, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrSKUTaken        = errors.New("sku is already used by another of the seller's variants")
+	// ErrPriceOverrides prevents changing the currency of a product whose
+	// variants override its price, since overrides are in its currency.
+	ErrPriceOverrides = errors.New("variants override the price in the product's currency; clear their price overrides first")
+	// ErrOverrideCurrency reports a price override in another currency than
+	// the product's, which changed since the override was validated.
+	ErrOverrideCurrency = errors.New("price override is not in the product's currency")
+)
+
+type VariantRepository struct {
+	db *gorm.DB
+}
+
+func NewVariantRepository(db *gorm.DB) *VariantRepository {
+	return &VariantRepository{db: db}
+}
+
+// Create adds a variant to its product, which it takes the seller from.
+// currency is the currency of the variant's price override, if it has one.
+func (r *VariantRepository) Create(ctx context.Context, variant *entity.Variant, currency string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		product, err := lockOverrideProduct(tx, uint64(variant.ProductID), variant, currency)
+		if err != nil {
+			return err
+		}
+		variant.SellerID, variant.OrgID = product.UserID, product.OrgID
+		return tx.Omit(clause.Associations).Create(variant).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrSKUTaken
+	}
+	return err
+}
+
+// lockOverrideProduct locks the product of the variant and checks that the
+// variant's price override is in its currency. Changing a product's price
+// takes the same lock, so its currency cannot change under the check.
+func lockOverrideProduct(tx *gorm.DB, productID uint64, variant *entity.Variant, currency string) (*entity.Product, error) {
+	var product entity.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	if variant.PriceOverrideMinor != nil && currency != product.Currency {
+		return nil, ErrOverrideCurrency
+	}
+	return &product, nil
+}
+
+func (r *VariantRepository) ListByProductID(ctx context.Context, productID uint64) ([]*entity.Variant, error) {
+	var variants []*entity.Variant
+	if err := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("id").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// Get returns a variant of the product.
+func (r *VariantRepository) Get(ctx context.Context, productID, id uint64) (*entity.Variant, error) {
+	var variant entity.Variant
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).First(&variant, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// Update replaces the editable fields of a variant of the product. currency
+// is the currency of the variant's price override, if it has one.
+func (r *VariantRepository) Update(ctx context.Context, productID uint64, variant *entity.Variant, currency string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockOverrideProduct(tx, productID, variant, currency); err != nil {
+			return err
+		}
+
+		result := tx.Model(&entity.Variant{}).
+			Where("id = ? AND product_id = ?", variant.ID, productID).
+			Updates(map[string]interface{}{
+				"sku":                  variant.SKU,
+				"options":              variant.Options,
+				"price_override_minor": variant.PriceOverrideMinor,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVariantNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrSKUTaken
+	}
+	return err
+}
+
+// Delete removes a variant of the product. Its pending reservations are
+// released on behalf of the actor and its inventory item is archived rather
+// than deleted, so its ledger and reservations stay on record.
+func (r *VariantRepository) Delete(ctx context.Context, productID, id, actorID uint64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("product_id = ?", productID).Delete(&entity.Variant{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVariantNotFound
+		}
+
+		var item entity.InventoryItem
+		err := lockItem(tx, &item, "product_id = ? AND variant_id = ? AND archived_at IS NULL", productID, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var reservations []*entity.Reservation
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("item_id = ? AND status = ?", item.ID, entity.ReservationPending).
+			Order("id").
+			Find(&reservations).Error
+		if err != nil {
+			return err
+		}
+		for _, reservation := range reservations {
+			if err := closeReservation(tx, reservation, entity.ReservationReleased, actorID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&item).Update("archived_at", time.Now()).Error
+	})
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+var ErrInvalidAttributes = errors.New("invalid attributes")
+
+const (
+	maxAttributes           = 50
+	maxAttributeValueLength = 200
+	maxEnumValues           = 100
+	maxAttributeFilters     = 10
+)
+
+var attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+var attributeTypes = map[string]bool{
+	entity.AttributeString:  true,
+	entity.AttributeNumber:  true,
+	entity.AttributeInteger: true,
+	entity.AttributeBoolean: true,
+	entity.AttributeEnum:    true,
+}
+
+// validateSchema checks the attribute schema of a category.
+func validateSchema(schema entity.AttributeSchema) (entity.AttributeSchema, error) {
+	if schema == nil {
+		return entity.AttributeSchema{}, nil
+	}
+	if len(schema) > maxAttributes {
+		return nil, fmt.Errorf("%w: at most %d attributes are allowed", ErrInvalidCategory, maxAttributes)
+	}
+
+	seen := make(map[string]bool, len(schema))
+	for i, def := range schema {
+		if !attributeNamePattern.MatchString(def.Name) {
+			return nil, fmt.Errorf("%w: attribute name %q must be lowercase letters, digits and underscores", ErrInvalidCategory, def.Name)
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("%w: attribute %q is declared twice", ErrInvalidCategory, def.Name)
+		}
+		seen[def.Name] = true
+
+		if !attributeTypes[def.Type] {
+			return nil, fmt.Errorf("%w: attribute %q has unknown type %q", ErrInvalidCategory, def.Name, def.Type)
+		}
+		if def.Type == entity.AttributeEnum {
+			if len(def.Values) == 0 || len(def.Values) > maxEnumValues {
+				return nil, fmt.Errorf("%w: enum attribute %q needs 1-%d values", ErrInvalidCategory, def.Name, maxEnumValues)
+			}
+			values := make(map[string]bool, len(def.Values))
+			for _, value := range def.Values {
+				if value == "" || values[value] {
+					return nil, fmt.Errorf("%w: enum attribute %q has empty or duplicate values", ErrInvalidCategory, def.Name)
+				}
+				values[value] = true
+			}
+		} else if len(def.Values) > 0 {
+			return nil, fmt.Errorf("%w: only enum attributes take values", ErrInvalidCategory)
+		}
+		if def.Unit != "" && def.Type != entity.AttributeNumber && def.Type != entity.AttributeInteger {
+			return nil, fmt.Errorf("%w: only numeric attributes take a unit", ErrInvalidCategory)
+		}
+		schema[i].Unit = strings.TrimSpace(def.Unit)
+	}
+	return schema, nil
+}
+
+// categorySchema merges the attribute schemas of the categories and their
+// ancestors. A subcategory's declaration of an attribute overrides its
+// ancestors' one.
+func (s *ProductService) categorySchema(ctx context.Context, categories []*entity.Category) (map[string]entity.AttributeDef, error) {
+	ancestors, err := s.categoryRepo.Ancestors(ctx, categories)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]*entity.Category, 0, len(ancestors))
+	for _, category := range ancestors {
+		ordered = append(ordered, category)
+	}
+	// Ancestors come first; a path's length in characters says nothing about
+	// its depth, as "/100/" and "/1/2/" show
+	depth := func(category *entity.Category) int {
+		return len(repository.PathIDs(category.Path))
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if depth(ordered[i]) != depth(ordered[j]) {
+			return depth(ordered[i]) < depth(ordered[j])
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	schema := make(map[string]entity.AttributeDef)
+	for _, category := range ordered {
+		for _, def := range category.AttributeSchema {
+			schema[def.Name] = def
+		}
+	}
+	return schema, nil
+}
+
+// productSchema returns the attribute schema of the product's categories.
+func (s *ProductService) productSchema(ctx context.Context, productID uint64) (map[string]entity.AttributeDef, error) {
+	categories, err := s.categoryRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.categorySchema(ctx, categories)
+}
+
+// validateAttributes checks attribute values against the schema. Attributes
+// the schema does not declare may hold any string, number or boolean.
+func validateAttributes(attributes entity.Attributes, schema map[string]entity.AttributeDef) error {
+	if len(attributes) > maxAttributes {
+		return fmt.Errorf("%w: at most %d attributes are allowed", ErrInvalidAttributes, maxAttributes)
+	}
+
+	for name, value := range attributes {
+		if !attributeNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: name %q must be lowercase letters, digits and underscores", ErrInvalidAttributes, name)
+		}
+		switch v := value.(type) {
+		case string:
+			if len(v) > maxAttributeValueLength {
+				return fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidAttributes, name, maxAttributeValueLength)
+			}
+		case float64, bool:
+		default:
+			return fmt.Errorf("%w: %s must be a string, number or boolean", ErrInvalidAttributes, name)
+		}
+
+		if def, ok := schema[name]; ok {
+			if err := checkAttribute(def, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := attributes[name]; schema[name].Required && !ok {
+			return fmt.Errorf("%w: %s is required", ErrInvalidAttributes, name)
+		}
+	}
+	return nil
+}
+
+func checkAttribute(def entity.AttributeDef, value interface{}) error {
+	switch def.Type {
+	case entity.AttributeString:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+	case entity.AttributeNumber:
+		if _, ok := value.(float64); ok {
+			return nil
+		}
+	case entity.AttributeInteger:
+		if v, ok := value.(float64); ok && v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return nil
+		}
+	case entity.AttributeBoolean:
+		if _, ok := value.(bool); ok {
+			return nil
+		}
+	case entity.AttributeEnum:
+		if v, ok := value.(string); ok {
+			for _, allowed := range def.Values {
+				if v == allowed {
+					return nil
+				}
+			}
+			return fmt.Errorf("%w: %s must be one of %s", ErrInvalidAttributes, def.Name, strings.Join(def.Values, ", "))
+		}
+	}
+	return fmt.Errorf("%w: %s must be of type %s", ErrInvalidAttributes, def.Name, def.Type)
+}
+
+// validateAttributeFilter checks the attribute filter of a listing.
+func validateAttributeFilter(filter map[string][]string) error {
+	if len(filter) > maxAttributeFilters {
+		return fmt.Errorf("%w: at most %d attribute filters are allowed", ErrInvalidFilter, maxAttributeFilters)
+	}
+	for name, values := range filter {
+		if !attributeNamePattern.MatchString(name) {
+			return fmt.Errorf("%w: invalid attribute name %q", ErrInvalidFilter, name)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("%w: attribute %s needs a value", ErrInvalidFilter, name)
+		}
+	}
+	return nil
+}
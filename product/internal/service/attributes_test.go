@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+)
+
+func TestValidateAttributes(t *testing.T) {
+	schema := map[string]entity.AttributeDef{
+		"brand":   {Name: "brand", Type: entity.AttributeString, Required: true},
+		"voltage": {Name: "voltage", Type: entity.AttributeNumber, Unit: "V"},
+		"ports":   {Name: "ports", Type: entity.AttributeInteger},
+		"wifi":    {Name: "wifi", Type: entity.AttributeBoolean},
+		"color":   {Name: "color", Type: entity.AttributeEnum, Values: []string{"red", "blue"}},
+	}
+
+	tooMany := entity.Attributes{}
+	for i := 0; i <= maxAttributes; i++ {
+		tooMany[fmt.Sprintf("a%d", i)] = "x"
+	}
+
+	tests := []struct {
+		name       string
+		attributes entity.Attributes
+		schema     map[string]entity.AttributeDef
+		wantErr    bool
+	}{
+		{"no schema, no attributes", nil, nil, false},
+		{"no schema, free attributes", entity.Attributes{"material": "wool", "weight": 1.5, "vegan": true}, nil, false},
+		{"all declared", entity.Attributes{"brand": "Acme", "voltage": 220.5, "ports": 4.0, "wifi": true, "color": "red"}, schema, false},
+		{"undeclared allowed", entity.Attributes{"brand": "Acme", "material": "steel"}, schema, false},
+		{"required missing", entity.Attributes{"voltage": 220.0}, schema, true},
+		{"string for number", entity.Attributes{"brand": "Acme", "voltage": "220"}, schema, true},
+		{"fraction for integer", entity.Attributes{"brand": "Acme", "ports": 2.5}, schema, true},
+		{"integer too large", entity.Attributes{"brand": "Acme", "ports": float64(1<<53) * 2}, schema, true},
+		{"string for boolean", entity.Attributes{"brand": "Acme", "wifi": "yes"}, schema, true},
+		{"unknown enum value", entity.Attributes{"brand": "Acme", "color": "green"}, schema, true},
+		{"number for enum", entity.Attributes{"brand": "Acme", "color": 1.0}, schema, true},
+		{"nested value", entity.Attributes{"dimensions": map[string]interface{}{"w": 1.0}}, nil, true},
+		{"list value", entity.Attributes{"sizes": []interface{}{"s", "m"}}, nil, true},
+		{"null value", entity.Attributes{"size": nil}, nil, true},
+		{"invalid name", entity.Attributes{"Brand": "Acme"}, nil, true},
+		{"name with dash", entity.Attributes{"screen-size": 15.6}, nil, true},
+		{"value too long", entity.Attributes{"note": strings.Repeat("x", maxAttributeValueLength+1)}, nil, true},
+		{"too many", tooMany, nil, true},
+	}
+
+	for _, tt := range tests {
+		err := validateAttributes(tt.attributes, tt.schema)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("%s: validateAttributes(%v) error = %v, want error %v", tt.name, tt.attributes, err, tt.wantErr)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrInvalidAttributes) {
+			t.Errorf("%s: validateAttributes(%v) error = %v, want %v", tt.name, tt.attributes, err, ErrInvalidAttributes)
+		}
+	}
+}
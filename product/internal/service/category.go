@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+	pb_user "github.com/gauss2302/testcommm/product/proto/user"
+)
+
+var (
+	ErrNotAdmin        = errors.New("admin role required")
+	ErrInvalidCategory = errors.New("invalid category")
+)
+
+const roleAdmin = "admin"
+
+var (
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	slugInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// CategoryNode is a category with its subcategories.
+type CategoryNode struct {
+	*entity.Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// ProductCategoryInfo is a category assigned to a product, with the
+// breadcrumbs leading to it from the root.
+type ProductCategoryInfo struct {
+	*entity.Category
+	Breadcrumbs []*entity.Category `json:"breadcrumbs"`
+}
+
+// requireAdmin checks the user's role with the user service.
+func (s *ProductService) requireAdmin(ctx context.Context, userID uint64) error {
+	user, err := s.userClient.GetUserByID(ctx, &pb_user.GetUserByIDRequest{Id: userID})
+	if err != nil {
+		return err
+	}
+	if user.Role != roleAdmin {
+		return ErrNotAdmin
+	}
+	return nil
+}
+
+// ListCategories returns the category tree.
+func (s *ProductService) ListCategories(ctx context.Context) ([]*CategoryNode, error) {
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parents sort before their children, so they are always seen first
+	nodes := make(map[uint]*CategoryNode, len(categories))
+	roots := []*CategoryNode{}
+	for _, category := range categories {
+		node := &CategoryNode{Category: category, Children: []*CategoryNode{}}
+		nodes[category.ID] = node
+		if category.ParentID == nil {
+			roots = append(roots, node)
+		} else if parent, ok := nodes[*category.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return roots, nil
+}
+
+func (s *ProductService) CreateCategory(ctx context.Context, userID uint64, name, slug string, parentID *uint, schema entity.AttributeSchema) (*entity.Category, error) {
+	if err := s.requireAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	name, slug, err := validateCategory(name, slug)
+	if err != nil {
+		return nil, err
+	}
+	if schema, err = validateSchema(schema); err != nil {
+		return nil, err
+	}
+
+	category := &entity.Category{Name: name, Slug: slug, ParentID: parentID, AttributeSchema: schema}
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// UpdateCategory renames and moves the category and, when schema is not nil,
+// replaces its attribute schema. Products already in the category keep their
+// attributes until they are next updated.
+func (s *ProductService) UpdateCategory(ctx context.Context, userID uint64, id uint, name, slug string, parentID *uint, schema *entity.AttributeSchema) (*entity.Category, error) {
+	if err := s.requireAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	name, slug, err := validateCategory(name, slug)
+	if err != nil {
+		return nil, err
+	}
+	if schema != nil {
+		validated, err := validateSchema(*schema)
+		if err != nil {
+			return nil, err
+		}
+		schema = &validated
+	}
+
+	return s.categoryRepo.Update(ctx, id, name, slug, parentID, schema)
+}
+
+func (s *ProductService) DeleteCategory(ctx context.Context, userID uint64, id uint) error {
+	if err := s.requireAdmin(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.categoryRepo.Delete(ctx, id)
+}
+
+// validateCategory trims the name and checks the slug, deriving it from the
+// name when empty.
+func validateCategory(name, slug string) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+
+	if slug == "" {
+		slug = strings.Trim(slugInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	}
+	if !slugPattern.MatchString(slug) {
+		return "", "", fmt.Errorf("%w: slug must consist of lowercase letters, digits and dashes", ErrInvalidCategory)
+	}
+	return name, slug, nil
+}
+
+// SetProductCategories replaces the categories of a product the user may
+// modify. The product's attributes must satisfy the schemas of its new
+// categories.
+func (s *ProductService) SetProductCategories(ctx context.Context, productID, userID uint64, categoryIDs []uint) ([]*ProductCategoryInfo, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+
+	unique := uniqueIDs(categoryIDs)
+
+	categories, err := s.categoryRepo.ListByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.categorySchema(ctx, categories)
+	if err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAttributes(product.Attributes, schema); err != nil {
+		return nil, err
+	}
+
+	if err := s.categoryRepo.SetProductCategories(ctx, productID, unique); err != nil {
+		return nil, err
+	}
+	return s.GetProductCategories(ctx, productID, userID)
+}
+
+// GetProductCategories returns the product's categories with breadcrumbs.
+func (s *ProductService) GetProductCategories(ctx context.Context, productID, userID uint64) ([]*ProductCategoryInfo, error) {
+	if _, err := s.visibleProduct(ctx, productID, userID); err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	ancestors, err := s.categoryRepo.Ancestors(ctx, categories)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*ProductCategoryInfo, len(categories))
+	for i, category := range categories {
+		info := &ProductCategoryInfo{Category: category, Breadcrumbs: []*entity.Category{}}
+		for _, id := range repository.PathIDs(category.Path) {
+			if ancestor, ok := ancestors[id]; ok {
+				info.Breadcrumbs = append(info.Breadcrumbs, ancestor)
+			}
+		}
+		result[i] = info
+	}
+	return result, nil
+}
+
+// categoryPath resolves a category slug for filtering listings.
+func (s *ProductService) categoryPath(ctx context.Context, slug string) (string, error) {
+	category, err := s.categoryRepo.GetBySlug(ctx, slug)
+	if errors.Is(err, repository.ErrCategoryNotFound) {
+		return "", fmt.Errorf("%w: unknown category %q", ErrInvalidFilter, slug)
+	}
+	if err != nil {
+		return "", err
+	}
+	return category.Path, nil
+}
+
+// uniqueIDs returns the IDs without duplicates, in their original order.
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
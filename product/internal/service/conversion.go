@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/exchange"
+)
+
+// ConvertedPrice is a product price converted into the buyer's currency.
+type ConvertedPrice struct {
+	AmountMinor int64  `json:"amount_minor"`
+	Amount      string `json:"amount"`
+	Currency    string `json:"currency"`
+	Formatted   string `json:"formatted"`
+	// Rate is how many units of Currency one unit of the product's
+	// currency buys.
+	Rate      string    `json:"rate"`
+	Source    string    `json:"source"`
+	RatesAsOf time.Time `json:"rates_as_of"`
+}
+
+// ConvertPrices converts the effective prices of the products, with running
+// promotions applied, into the currency, keyed by product ID. Products priced
+// in a currency without an exchange rate are left out.
+func (s *ProductService) ConvertPrices(ctx context.Context, products []*entity.Product, currency string) (map[uint]*ConvertedPrice, error) {
+	currency = strings.ToUpper(currency)
+	if !money.ValidCurrency(currency) {
+		return nil, fmt.Errorf("%w: unknown currency %q", ErrInvalidCurrency, currency)
+	}
+	ok, err := s.converter.Supports(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %s", exchange.ErrUnsupportedCurrency, currency)
+	}
+
+	converted := make(map[uint]*ConvertedPrice, len(products))
+	for _, product := range products {
+		conversion, err := s.converter.Convert(ctx, product.EffectiveMoney(), currency)
+		if errors.Is(err, exchange.ErrUnsupportedCurrency) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		converted[product.ID] = &ConvertedPrice{
+			AmountMinor: conversion.Money.Amount,
+			Amount:      conversion.Money.Decimal(),
+			Currency:    conversion.Money.Currency,
+			Formatted:   conversion.Money.String(),
+			Rate:        strings.TrimRight(strings.TrimRight(conversion.Rate.FloatString(10), "0"), "."),
+			Source:      conversion.Source,
+			RatesAsOf:   conversion.AsOf,
+		}
+	}
+	return converted, nil
+}
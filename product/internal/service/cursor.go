@@ -0,0 +1,66 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+// pageCursor is the content of an opaque listing cursor. It records the sort
+// order it was issued for so it cannot be replayed against another one.
+type pageCursor struct {
+	Value      string `json:"v,omitempty"`
+	ID         uint   `json:"id"`
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Backward   bool   `json:"b,omitempty"`
+}
+
+// encodeCursor serializes the cursor and appends an HMAC so clients cannot
+// forge or tamper with it.
+func (s *ProductService) encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.signCursor(payload))
+}
+
+func (s *ProductService) decodeCursor(token string) (*pageCursor, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, s.signCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func (s *ProductService) signCursor(payload string) []byte {
+	mac := hmac.New(sha256.New, s.cursorSecret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func (s *ProductService) cursorFor(filter repository.ProductFilter, c repository.ProductCursor, backward bool) string {
+	return s.encodeCursor(pageCursor{
+		Value:      c.Value,
+		ID:         c.ID,
+		SortBy:     filter.SortBy,
+		Descending: filter.Descending,
+		Backward:   backward,
+	})
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	s := &ProductService{cursorSecret: []byte("secret")}
+
+	tests := []pageCursor{
+		{ID: 1, SortBy: "created_at"},
+		{Value: "2024-01-01T00:00:00Z", ID: 42, SortBy: "created_at", Descending: true},
+		{Value: "1999", ID: 7, SortBy: "price", Backward: true},
+		{Value: "name with \"quotes\" and . dots", ID: 3, SortBy: "name", Descending: true, Backward: true},
+	}
+
+	for _, want := range tests {
+		token := s.encodeCursor(want)
+		got, err := s.decodeCursor(token)
+		if err != nil {
+			t.Errorf("decodeCursor(encodeCursor(%+v)): %v", want, err)
+			continue
+		}
+		if *got != want {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", want, *got)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalid(t *testing.T) {
+	s := &ProductService{cursorSecret: []byte("secret")}
+	other := &ProductService{cursorSecret: []byte("other secret")}
+
+	valid := s.encodeCursor(pageCursor{Value: "1999", ID: 7, SortBy: "price"})
+	payload, sig, _ := strings.Cut(valid, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"v":"1","id":1,"s":"price"}`))
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("not json"))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", payload},
+		{"empty signature", payload + "."},
+		{"signature not base64", payload + ".!!!"},
+		{"tampered payload", forged + "." + sig},
+		{"signed with another secret", other.encodeCursor(pageCursor{ID: 7, SortBy: "price"})},
+		{"signed garbage", notJSON + "." + base64.RawURLEncoding.EncodeToString(s.signCursor(notJSON))},
+		{"payload not base64", "!!!." + base64.RawURLEncoding.EncodeToString(s.signCursor("!!!"))},
+	}
+
+	for _, tt := range tests {
+		if _, err := s.decodeCursor(tt.token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: decodeCursor(%q) error = %v, want %v", tt.name, tt.token, err, ErrInvalidCursor)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/imaging"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+var (
+	ErrInvalidImage  = errors.New("invalid image")
+	ErrImageTooLarge = errors.New("image is too large")
+)
+
+const (
+	// MaxImageSize bounds uploaded files in bytes.
+	MaxImageSize     = 10 << 20
+	maxProductImages = 20
+)
+
+// ThumbnailSizes are the thumbnails generated for every image, by the
+// maximum length of their longer side.
+var ThumbnailSizes = map[string]int{
+	"small":  150,
+	"medium": 400,
+	"large":  1024,
+}
+
+// imageTypes maps accepted content types to file extensions.
+var imageTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// UploadImage validates an uploaded image, stores it along with its
+// thumbnails and appends it to the product's images. contentType is the type
+// the client declared, if any; it must match the file's actual content.
+func (s *ProductService) UploadImage(ctx context.Context, productID, userID uint64, data []byte, contentType string) (*entity.ProductImage, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	if len(data) > MaxImageSize {
+		return nil, fmt.Errorf("%w: at most %d bytes are allowed", ErrImageTooLarge, MaxImageSize)
+	}
+
+	detected := http.DetectContentType(data)
+	ext, ok := imageTypes[detected]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported content type %s", ErrInvalidImage, detected)
+	}
+	if contentType != "" && contentType != "application/octet-stream" && contentType != detected {
+		return nil, fmt.Errorf("%w: declared %s but the file is %s", ErrInvalidImage, contentType, detected)
+	}
+
+	// Fail early before processing the upload; Create enforces the limit
+	count, err := s.imageRepo.Count(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxProductImages {
+		return nil, fmt.Errorf("%w: a product can have at most %d images", ErrInvalidImage, maxProductImages)
+	}
+
+	img, _, err := imaging.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	// Thumbnails of images that may be transparent stay PNG
+	thumbFormat := "jpg"
+	if ext != "jpg" {
+		thumbFormat = "png"
+	}
+
+	token := make([]byte, 12)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	image := &entity.ProductImage{
+		ProductID:       uint(productID),
+		Key:             fmt.Sprintf("products/%d/%s/original.%s", productID, hex.EncodeToString(token), ext),
+		ContentType:     detected,
+		Size:            int64(len(data)),
+		Width:           img.Bounds().Dx(),
+		Height:          img.Bounds().Dy(),
+		ThumbnailFormat: thumbFormat,
+	}
+
+	stored := []string{}
+	cleanup := func() {
+		for _, key := range stored {
+			if err := s.blobs.Delete(context.WithoutCancel(ctx), key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+			}
+		}
+	}
+
+	if err := s.blobs.Put(ctx, image.Key, data, detected); err != nil {
+		return nil, err
+	}
+	stored = append(stored, image.Key)
+
+	for name, thumbImg := range imaging.Thumbnails(img, ThumbnailSizes) {
+		thumb, thumbType, err := imaging.Encode(thumbImg, thumbFormat == "png")
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		key := thumbnailKey(image, name)
+		if err := s.blobs.Put(ctx, key, thumb, thumbType); err != nil {
+			cleanup()
+			return nil, err
+		}
+		stored = append(stored, key)
+	}
+
+	if err := s.imageRepo.Create(ctx, image, maxProductImages); err != nil {
+		cleanup()
+		if errors.Is(err, repository.ErrImageLimit) {
+			return nil, fmt.Errorf("%w: a product can have at most %d images", ErrInvalidImage, maxProductImages)
+		}
+		return nil, err
+	}
+	if err := s.resubmit(ctx, productID); err != nil {
+		return nil, err
+	}
+	s.setImageURLs(image)
+	return image, nil
+}
+
+func (s *ProductService) ListImages(ctx context.Context, productID, userID uint64) ([]*entity.ProductImage, error) {
+	if _, err := s.visibleProduct(ctx, productID, userID); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.ListByProductIDs(ctx, []uint{uint(productID)})
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		s.setImageURLs(image)
+	}
+	return images, nil
+}
+
+func (s *ProductService) DeleteImage(ctx context.Context, productID, id, userID uint64) error {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return err
+	}
+
+	image, err := s.imageRepo.Delete(ctx, productID, id)
+	if err != nil {
+		return err
+	}
+	s.deleteImageBlobs(ctx, []*entity.ProductImage{image})
+	return nil
+}
+
+// ReorderImages sets the display order of the product's images. ids must
+// list every image of the product exactly once.
+func (s *ProductService) ReorderImages(ctx context.Context, productID, userID uint64, ids []uint) ([]*entity.ProductImage, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+
+	images, err := s.imageRepo.ListByProductIDs(ctx, []uint{uint(productID)})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[uint]bool, len(images))
+	for _, image := range images {
+		existing[image.ID] = true
+	}
+	if len(ids) != len(existing) {
+		return nil, fmt.Errorf("%w: the order must list all %d images", ErrInvalidImage, len(existing))
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			return nil, fmt.Errorf("%w: image %d is not an image of the product or is listed twice", ErrInvalidImage, id)
+		}
+		delete(existing, id)
+	}
+
+	if err := s.imageRepo.Reorder(ctx, productID, ids); err != nil {
+		return nil, err
+	}
+	return s.ListImages(ctx, productID, userID)
+}
+
+func (s *ProductService) SetPrimaryImage(ctx context.Context, productID, id, userID uint64) ([]*entity.ProductImage, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+
+	if err := s.imageRepo.SetPrimary(ctx, productID, id); err != nil {
+		return nil, err
+	}
+	return s.ListImages(ctx, productID, userID)
+}
+
+// loadImages fills in the images of the given products.
+func (s *ProductService) loadImages(ctx context.Context, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[uint]*entity.Product, len(products))
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		product.Images = []*entity.ProductImage{}
+		byID[product.ID] = product
+		ids[i] = product.ID
+	}
+
+	images, err := s.imageRepo.ListByProductIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		s.setImageURLs(image)
+		if product, ok := byID[image.ProductID]; ok {
+			product.Images = append(product.Images, image)
+		}
+	}
+	return nil
+}
+
+func (s *ProductService) setImageURLs(image *entity.ProductImage) {
+	image.URL = s.blobs.URL(image.Key)
+	image.Thumbnails = make(map[string]string, len(ThumbnailSizes))
+	for name := range ThumbnailSizes {
+		image.Thumbnails[name] = s.blobs.URL(thumbnailKey(image, name))
+	}
+}
+
+// deleteImageBlobs removes the stored files of deleted images. Failures only
+// leave orphaned files behind, so they are logged rather than returned.
+func (s *ProductService) deleteImageBlobs(ctx context.Context, images []*entity.ProductImage) {
+	for _, image := range images {
+		keys := []string{image.Key}
+		for name := range ThumbnailSizes {
+			keys = append(keys, thumbnailKey(image, name))
+		}
+		for _, key := range keys {
+			if err := s.blobs.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+			}
+		}
+	}
+}
+
+func thumbnailKey(image *entity.ProductImage, size string) string {
+	return path.Join(path.Dir(image.Key), size+"."+image.ThumbnailFormat)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+var ErrInvalidInventory = errors.New("invalid inventory request")
+
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	maxReservationTTL     = 24 * time.Hour
+	maxNoteLength         = 500
+	// expiryBatchSize bounds the reservations one sweep releases per
+	// transaction.
+	expiryBatchSize = 100
+)
+
+// GetInventory returns the stock of the product and its variants.
+func (s *ProductService) GetInventory(ctx context.Context, productID, userID uint64) ([]*entity.InventoryItem, error) {
+	if _, err := s.visibleProduct(ctx, productID, userID); err != nil {
+		return nil, err
+	}
+	return s.inventoryRepo.ListByProductID(ctx, productID)
+}
+
+// AdjustInventory adds delta units, which may be negative, to the stock on
+// hand of the product or one of its variants.
+func (s *ProductService) AdjustInventory(ctx context.Context, productID, variantID, userID uint64, delta int, note string) (*entity.InventoryItem, error) {
+	if delta == 0 {
+		return nil, fmt.Errorf("%w: delta must not be zero", ErrInvalidInventory)
+	}
+	if len(note) > maxNoteLength {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrInvalidInventory, maxNoteLength)
+	}
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	if err := s.checkVariant(ctx, productID, variantID); err != nil {
+		return nil, err
+	}
+
+	return s.inventoryRepo.Adjust(ctx, repository.InventoryChange{
+		ProductID: productID,
+		VariantID: variantID,
+		Delta:     delta,
+		ActorID:   userID,
+		Note:      note,
+	})
+}
+
+// LedgerPage is a page of inventory ledger entries, newest first.
+type LedgerPage struct {
+	Entries []*entity.InventoryEntry `json:"entries"`
+	Total   int64                    `json:"total"`
+	Page    int32                    `json:"page"`
+	PerPage int32                    `json:"per_page"`
+}
+
+// ListInventoryLedger returns the stock changes of the product, optionally
+// limited to one variant. Only the product's sellers can see it.
+func (s *ProductService) ListInventoryLedger(ctx context.Context, productID, userID uint64, variantID *uint64, page, perPage int32) (*LedgerPage, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 10
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	entries, total, err := s.inventoryRepo.Ledger(ctx, productID, variantID, int((page-1)*perPage), int(perPage))
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerPage{
+		Entries: entries,
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+	}, nil
+}
+
+// ReserveStock holds quantity units of the product or one of its variants for
+// the user. The reservation is released automatically after ttl, which
+// defaults to DefaultReservationTTL.
+func (s *ProductService) ReserveStock(ctx context.Context, productID, variantID, userID uint64, quantity int, ttl time.Duration) (*entity.Reservation, error) {
+	if quantity < 1 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalidInventory)
+	}
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	if ttl < 0 || ttl > maxReservationTTL {
+		return nil, fmt.Errorf("%w: ttl must be at most %s", ErrInvalidInventory, maxReservationTTL)
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status != entity.StatusPublished {
+		return nil, fmt.Errorf("%w: product is not published", ErrInvalidInventory)
+	}
+	if err := s.checkVariant(ctx, productID, variantID); err != nil {
+		return nil, err
+	}
+
+	reservation := &entity.Reservation{
+		ProductID: uint(productID),
+		VariantID: uint(variantID),
+		Quantity:  quantity,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.inventoryRepo.Reserve(ctx, reservation); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// GetReservation returns a reservation to the user who made it or a seller of
+// the product.
+func (s *ProductService) GetReservation(ctx context.Context, id, userID uint64) (*entity.Reservation, error) {
+	reservation, err := s.inventoryRepo.GetReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeReservation(ctx, reservation, userID); err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// CommitReservation turns a pending reservation into a sale. Products in the
+// trash cannot be sold.
+func (s *ProductService) CommitReservation(ctx context.Context, id, userID uint64) (*entity.Reservation, error) {
+	reservation, err := s.GetReservation(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.productRepo.GetByID(ctx, uint64(reservation.ProductID))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: the product was deleted", repository.ErrReservationClosed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.inventoryRepo.Commit(ctx, id, userID, time.Now())
+}
+
+// ReleaseReservation cancels a pending reservation.
+func (s *ProductService) ReleaseReservation(ctx context.Context, id, userID uint64) (*entity.Reservation, error) {
+	if _, err := s.GetReservation(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.inventoryRepo.Release(ctx, id, userID)
+}
+
+func (s *ProductService) authorizeReservation(ctx context.Context, reservation *entity.Reservation, userID uint64) error {
+	if reservation.UserID == userID {
+		return nil
+	}
+	return s.authorize(ctx, uint64(reservation.ProductID), userID, orgRoleMember)
+}
+
+// checkVariant makes sure a non-zero variant ID belongs to the product.
+func (s *ProductService) checkVariant(ctx context.Context, productID, variantID uint64) error {
+	if variantID == 0 {
+		return nil
+	}
+	_, err := s.variantRepo.Get(ctx, productID, variantID)
+	return err
+}
+
+// ExpireReservations periodically releases reservations whose TTL has passed
+// until ctx is cancelled.
+func (s *ProductService) ExpireReservations(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			n, err := s.inventoryRepo.ExpireReservations(ctx, time.Now(), expiryBatchSize)
+			if err != nil {
+				log.Printf("Failed to expire reservations: %v", err)
+				break
+			}
+			if n > 0 {
+				log.Printf("Released %d expired reservations", n)
+			}
+			if n < expiryBatchSize {
+				break
+			}
+		}
+	}
+}
+
+// loadVariantStock fills in the stock of the product's variants.
+func (s *ProductService) loadVariantStock(ctx context.Context, productID uint64, variants []*entity.Variant) error {
+	items, err := s.inventoryRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	byVariant := make(map[uint]*entity.InventoryItem, len(items))
+	for _, item := range items {
+		byVariant[item.VariantID] = item
+	}
+	for _, variant := range variants {
+		if item, ok := byVariant[variant.ID]; ok {
+			variant.Stock = item.OnHand
+			variant.Available = item.Available
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInventoryRequestsRejected(t *testing.T) {
+	// Every request here fails validation before the repositories are used
+	s := &ProductService{}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"zero adjustment", func() error {
+			_, err := s.AdjustInventory(ctx, 1, 0, 10, 0, "")
+			return err
+		}},
+		{"note too long", func() error {
+			_, err := s.AdjustInventory(ctx, 1, 0, 10, 5, strings.Repeat("x", maxNoteLength+1))
+			return err
+		}},
+		{"zero quantity", func() error {
+			_, err := s.ReserveStock(ctx, 1, 0, 10, 0, 0)
+			return err
+		}},
+		{"negative quantity", func() error {
+			_, err := s.ReserveStock(ctx, 1, 0, 10, -1, 0)
+			return err
+		}},
+		{"negative ttl", func() error {
+			_, err := s.ReserveStock(ctx, 1, 0, 10, 1, -time.Minute)
+			return err
+		}},
+		{"ttl too long", func() error {
+			_, err := s.ReserveStock(ctx, 1, 0, 10, 1, maxReservationTTL+time.Second)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, ErrInvalidInventory) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, ErrInvalidInventory)
+		}
+	}
+}
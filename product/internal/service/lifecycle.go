@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+const maxReviewNoteLength = 1000
+
+var productStatuses = map[string]bool{
+	entity.StatusDraft:         true,
+	entity.StatusPendingReview: true,
+	entity.StatusPublished:     true,
+	entity.StatusArchived:      true,
+}
+
+// The statuses each action moves a product from.
+var (
+	publishableFrom   = []string{entity.StatusDraft, entity.StatusArchived}
+	unpublishableFrom = []string{entity.StatusPublished, entity.StatusPendingReview}
+	archivableFrom    = []string{entity.StatusDraft, entity.StatusPendingReview, entity.StatusPublished}
+	reviewableFrom    = []string{entity.StatusPendingReview}
+)
+
+// ViewProduct returns a product as the user may see it, with its tags, images
+// and promotion.
+func (s *ProductService) ViewProduct(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+	if _, err := s.visibleProduct(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.GetProduct(ctx, id)
+}
+
+// visibleProduct returns the product if the user may see it. Products that
+// are not published are only visible to those who manage them and to
+// moderators; everyone else gets gorm.ErrRecordNotFound. Every read of a
+// product or of data scoped to it goes through here.
+func (s *ProductService) visibleProduct(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product.Status == entity.StatusPublished {
+		return product, nil
+	}
+
+	err = s.authorizeProduct(ctx, product, userID, orgRoleMember)
+	if errors.Is(err, ErrForbidden) {
+		err = s.requireAdmin(ctx, userID)
+	}
+	if errors.Is(err, ErrNotAdmin) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// PublishProduct makes a draft or archived product public, or submits it for
+// review when moderation is enabled. With moderation, a published product
+// whose content, images or variants change goes back to review; scheduled
+// price changes and promotions do not, as they only change the price.
+func (s *ProductService) PublishProduct(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+	return s.transition(ctx, id, userID, publishableFrom, s.publishStatus())
+}
+
+// publishStatus is the status publishing moves a product to.
+func (s *ProductService) publishStatus() string {
+	if s.requireReview {
+		return entity.StatusPendingReview
+	}
+	return entity.StatusPublished
+}
+
+// UnpublishProduct takes a published product, or one awaiting review, back
+// to draft.
+func (s *ProductService) UnpublishProduct(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+	return s.transition(ctx, id, userID, unpublishableFrom, entity.StatusDraft)
+}
+
+// ArchiveProduct retires a product; it can be published again later.
+func (s *ProductService) ArchiveProduct(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+	return s.transition(ctx, id, userID, archivableFrom, entity.StatusArchived)
+}
+
+func (s *ProductService) transition(ctx context.Context, id, userID uint64, from []string, status string) (*entity.Product, error) {
+	if err := s.authorize(ctx, id, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	return s.applyTransition(ctx, id, from, status, "")
+}
+
+// ReviewProduct approves a product awaiting review, publishing it, or
+// rejects it back to draft with a note for its owner. Only admins moderate.
+func (s *ProductService) ReviewProduct(ctx context.Context, id, moderatorID uint64, approve bool, note string) (*entity.Product, error) {
+	if err := s.requireAdmin(ctx, moderatorID); err != nil {
+		return nil, err
+	}
+
+	note = strings.TrimSpace(note)
+	if len(note) > maxReviewNoteLength {
+		return nil, fmt.Errorf("%w: note must be at most %d characters", ErrInvalidTransition, maxReviewNoteLength)
+	}
+	status := entity.StatusDraft
+	if approve {
+		status = entity.StatusPublished
+	}
+	return s.applyTransition(ctx, id, reviewableFrom, status, note)
+}
+
+// ListPendingProducts returns the moderation queue, oldest first.
+func (s *ProductService) ListPendingProducts(ctx context.Context, moderatorID uint64, req PageRequest) (*ProductPage, error) {
+	if err := s.requireAdmin(ctx, moderatorID); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, repository.ProductFilter{
+		Status:        entity.StatusPendingReview,
+		IncludeHidden: true,
+		SortBy:        "id",
+	}, req)
+}
+
+func (s *ProductService) applyTransition(ctx context.Context, id uint64, from []string, status, note string) (*entity.Product, error) {
+	ok, err := s.productRepo.Transition(ctx, id, from, status, note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		product, err := s.productRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: product is %s", ErrInvalidTransition, product.Status)
+	}
+	return s.GetProduct(ctx, id)
+}
+
+// resubmit sends a published product back to review after its content
+// changed, when moderation is enabled, so that changes cannot go live
+// unreviewed.
+func (s *ProductService) resubmit(ctx context.Context, id uint64) error {
+	if !s.requireReview {
+		return nil
+	}
+	_, err := s.productRepo.Transition(ctx, id, []string{entity.StatusPublished}, entity.StatusPendingReview, "")
+	return err
+}
+
+// contentChanged reports whether an update changes what buyers see of the
+// product. Zero and nil fields of the update leave the product unchanged.
+func contentChanged(current, update *entity.Product) bool {
+	if update.Name != "" && update.Name != current.Name {
+		return true
+	}
+	if update.Description != "" && update.Description != current.Description {
+		return true
+	}
+	if update.PriceMinor != 0 && update.Money() != current.Money() {
+		return true
+	}
+	if update.Tags != nil && !reflect.DeepEqual(sortedCopy(update.Tags), sortedCopy(current.Tags)) {
+		return true
+	}
+	return update.Attributes != nil && !reflect.DeepEqual(update.Attributes, current.Attributes)
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func validateStatus(status string) error {
+	if status != "" && !productStatuses[status] {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, status)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+)
+
+func TestLifecycleTransitions(t *testing.T) {
+	const (
+		draft     = entity.StatusDraft
+		pending   = entity.StatusPendingReview
+		published = entity.StatusPublished
+		archived  = entity.StatusArchived
+	)
+	actions := map[string][]string{
+		"publish":   publishableFrom,
+		"unpublish": unpublishableFrom,
+		"archive":   archivableFrom,
+		"review":    reviewableFrom,
+	}
+
+	tests := []struct {
+		action string
+		from   string
+		want   bool
+	}{
+		{"publish", draft, true},
+		{"publish", archived, true},
+		{"publish", pending, false},
+		{"publish", published, false},
+		{"unpublish", published, true},
+		{"unpublish", pending, true},
+		{"unpublish", draft, false},
+		{"unpublish", archived, false},
+		{"archive", draft, true},
+		{"archive", pending, true},
+		{"archive", published, true},
+		{"archive", archived, false},
+		{"review", pending, true},
+		{"review", draft, false},
+		{"review", published, false},
+		{"review", archived, false},
+	}
+
+	for _, tt := range tests {
+		got := false
+		for _, status := range actions[tt.action] {
+			got = got || status == tt.from
+		}
+		if got != tt.want {
+			t.Errorf("%s from %s allowed = %v, want %v", tt.action, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestPublishStatus(t *testing.T) {
+	if got := (&ProductService{}).publishStatus(); got != entity.StatusPublished {
+		t.Errorf("publishStatus without review = %s, want %s", got, entity.StatusPublished)
+	}
+	if got := (&ProductService{requireReview: true}).publishStatus(); got != entity.StatusPendingReview {
+		t.Errorf("publishStatus with review = %s, want %s", got, entity.StatusPendingReview)
+	}
+}
+
+func TestReviewProductRejects(t *testing.T) {
+	// Every request here fails before the repositories are used
+	s := &ProductService{userClient: testUsers()}
+
+	tests := []struct {
+		name        string
+		moderatorID uint64
+		note        string
+		wantErr     error
+	}{
+		{"not an admin", 10, "", ErrNotAdmin},
+		{"org owner is not a moderator", 11, "looks good", ErrNotAdmin},
+		{"note too long", 99, strings.Repeat("x", maxReviewNoteLength+1), ErrInvalidTransition},
+	}
+
+	for _, tt := range tests {
+		_, err := s.ReviewProduct(context.Background(), 1, tt.moderatorID, false, tt.note)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: ReviewProduct error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestContentChanged(t *testing.T) {
+	current := &entity.Product{
+		Name:        "Shirt",
+		Description: "Cotton",
+		PriceMinor:  1000,
+		Currency:    "USD",
+		Tags:        []string{"summer", "cotton"},
+		Attributes:  entity.Attributes{"size": "M"},
+	}
+
+	tests := []struct {
+		name   string
+		update *entity.Product
+		want   bool
+	}{
+		{"empty update", &entity.Product{}, false},
+		{"same values", &entity.Product{Name: "Shirt", Description: "Cotton", PriceMinor: 1000, Currency: "USD"}, false},
+		{"tags reordered", &entity.Product{Tags: []string{"cotton", "summer"}}, false},
+		{"same attributes", &entity.Product{Attributes: entity.Attributes{"size": "M"}}, false},
+		{"name", &entity.Product{Name: "T-shirt"}, true},
+		{"description", &entity.Product{Description: "Linen"}, true},
+		{"price", &entity.Product{PriceMinor: 900, Currency: "USD"}, true},
+		{"currency", &entity.Product{PriceMinor: 1000, Currency: "EUR"}, true},
+		{"tags", &entity.Product{Tags: []string{"summer"}}, true},
+		{"tags cleared", &entity.Product{Tags: []string{}}, true},
+		{"attributes", &entity.Product{Attributes: entity.Attributes{"size": "L"}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := contentChanged(current, tt.update); got != tt.want {
+			t.Errorf("%s: contentChanged = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
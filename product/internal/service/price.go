@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+)
+
+var ErrInvalidPromotion = errors.New("invalid promotion")
+
+const (
+	maxPromotions        = 20
+	maxPromotionDuration = 366 * 24 * time.Hour
+)
+
+// PriceHistoryPage is a page of price changes, newest first.
+type PriceHistoryPage struct {
+	Changes []*entity.PriceChange `json:"changes"`
+	Total   int64                 `json:"total"`
+	Page    int32                 `json:"page"`
+	PerPage int32                 `json:"per_page"`
+}
+
+// ListPriceHistory returns the price changes of the product, including the
+// starts and ends of its promotions. Only the product's sellers can see who
+// made them.
+func (s *ProductService) ListPriceHistory(ctx context.Context, productID, userID uint64, page, perPage int32) (*PriceHistoryPage, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 10
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	changes, total, err := s.priceRepo.History(ctx, productID, int((page-1)*perPage), int(perPage))
+	if err != nil {
+		return nil, err
+	}
+	return &PriceHistoryPage{
+		Changes: changes,
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+	}, nil
+}
+
+// PromotionInput describes a sale price for a period of time.
+type PromotionInput struct {
+	Price    money.Money
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+// CreatePromotion puts the product on sale between StartsAt and EndsAt. The
+// sale price must be in the product's currency and below its current price.
+func (s *ProductService) CreatePromotion(ctx context.Context, productID, userID uint64, input PromotionInput) (*entity.Promotion, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := validatePromotion(input, product, now); err != nil {
+		return nil, err
+	}
+
+	promotions, err := s.priceRepo.ListPromotions(ctx, productID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(promotions) >= maxPromotions {
+		return nil, fmt.Errorf("%w: a product can have at most %d upcoming promotions", ErrInvalidPromotion, maxPromotions)
+	}
+
+	promotion := &entity.Promotion{
+		ProductID: uint(productID),
+		StartsAt:  input.StartsAt,
+		EndsAt:    input.EndsAt,
+		CreatedBy: userID,
+	}
+	promotion.SetMoney(input.Price)
+	if err := s.priceRepo.CreatePromotion(ctx, promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// validatePromotion checks that the promotion ends after now, lasts at most
+// maxPromotionDuration and undercuts the product's price.
+func validatePromotion(input PromotionInput, product *entity.Product, now time.Time) error {
+	if err := validatePrice(input.Price); err != nil {
+		return err
+	}
+	if !input.EndsAt.After(input.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+	if input.EndsAt.Sub(input.StartsAt) > maxPromotionDuration {
+		return fmt.Errorf("%w: a promotion can last at most a year", ErrInvalidPromotion)
+	}
+	if !input.EndsAt.After(now) {
+		return fmt.Errorf("%w: ends_at must be in the future", ErrInvalidPromotion)
+	}
+	if input.Price.Currency != product.Currency {
+		return fmt.Errorf("%w: the sale price must be in %s", ErrInvalidPromotion, product.Currency)
+	}
+	if input.Price.Amount >= product.PriceMinor {
+		return fmt.Errorf("%w: the sale price must be below %s", ErrInvalidPromotion, product.Money())
+	}
+	return nil
+}
+
+// ListPromotions returns the running and upcoming promotions of the product.
+func (s *ProductService) ListPromotions(ctx context.Context, productID, userID uint64) ([]*entity.Promotion, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	return s.priceRepo.ListPromotions(ctx, productID, time.Now())
+}
+
+// DeletePromotion cancels a promotion, ending it early if it is running.
+func (s *ProductService) DeletePromotion(ctx context.Context, productID, id, userID uint64) error {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return err
+	}
+	return s.priceRepo.DeletePromotion(ctx, productID, id, userID, time.Now())
+}
+
+// recordPromotions records the promotions that started or ended in the price
+// history.
+func (s *ProductService) recordPromotions(ctx context.Context) error {
+	for {
+		n, err := s.priceRepo.RecordPromotions(ctx, time.Now(), scheduleBatchSize)
+		if err != nil {
+			return err
+		}
+		if n < scheduleBatchSize {
+			return nil
+		}
+	}
+}
+
+// loadPromotions fills in the running promotions of the given products. A
+// promotion only applies while it undercuts the product's price in the same
+// currency, which a later price change may no longer do.
+func (s *ProductService) loadPromotions(ctx context.Context, products []*entity.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[uint]*entity.Product, len(products))
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		byID[product.ID] = product
+		ids[i] = product.ID
+	}
+
+	promotions, err := s.priceRepo.ActivePromotions(ctx, ids, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, promotion := range promotions {
+		product, ok := byID[promotion.ProductID]
+		if !ok || !promotion.Applies(product) {
+			continue
+		}
+		product.Promotion = promotion
+	}
+	return nil
+}
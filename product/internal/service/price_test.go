@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+)
+
+func TestValidatePromotion(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	product := &entity.Product{PriceMinor: 1000, Currency: "USD"}
+	sale := money.Money{Amount: 800, Currency: "USD"}
+
+	tests := []struct {
+		name    string
+		input   PromotionInput
+		wantErr error
+	}{
+		{"upcoming", PromotionInput{Price: sale, StartsAt: now.Add(time.Hour), EndsAt: now.Add(48 * time.Hour)}, nil},
+		{"already running", PromotionInput{Price: sale, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}, nil},
+		{"a year long", PromotionInput{Price: sale, StartsAt: now, EndsAt: now.Add(maxPromotionDuration)}, nil},
+		{"longer than a year", PromotionInput{Price: sale, StartsAt: now, EndsAt: now.Add(maxPromotionDuration + time.Second)}, ErrInvalidPromotion},
+		{"ends when it starts", PromotionInput{Price: sale, StartsAt: now.Add(time.Hour), EndsAt: now.Add(time.Hour)}, ErrInvalidPromotion},
+		{"ends before it starts", PromotionInput{Price: sale, StartsAt: now.Add(2 * time.Hour), EndsAt: now.Add(time.Hour)}, ErrInvalidPromotion},
+		{"already ended", PromotionInput{Price: sale, StartsAt: now.Add(-2 * time.Hour), EndsAt: now}, ErrInvalidPromotion},
+		{"other currency", PromotionInput{Price: money.Money{Amount: 800, Currency: "EUR"}, StartsAt: now, EndsAt: now.Add(time.Hour)}, ErrInvalidPromotion},
+		{"equal to the price", PromotionInput{Price: money.Money{Amount: 1000, Currency: "USD"}, StartsAt: now, EndsAt: now.Add(time.Hour)}, ErrInvalidPromotion},
+		{"above the price", PromotionInput{Price: money.Money{Amount: 1200, Currency: "USD"}, StartsAt: now, EndsAt: now.Add(time.Hour)}, ErrInvalidPromotion},
+		{"zero price", PromotionInput{Price: money.Money{Currency: "USD"}, StartsAt: now, EndsAt: now.Add(time.Hour)}, ErrInvalidPrice},
+		{"unknown currency", PromotionInput{Price: money.Money{Amount: 800, Currency: "XXX"}, StartsAt: now, EndsAt: now.Add(time.Hour)}, ErrInvalidPrice},
+	}
+
+	for _, tt := range tests {
+		if err := validatePromotion(tt.input, product, now); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: validatePromotion error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -3,80 +3,539 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/product/internal/blob"
 	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/exchange"
 	"github.com/gauss2302/testcommm/product/internal/repository"
+	pb_user "github.com/gauss2302/testcommm/product/proto/user"
 )
 
 type ProductService struct {
-	productRepo *repository.ProductRepository
+	productRepo   *repository.ProductRepository
+	categoryRepo  *repository.CategoryRepository
+	variantRepo   *repository.VariantRepository
+	inventoryRepo *repository.InventoryRepository
+	imageRepo     *repository.ImageRepository
+	priceRepo     *repository.PriceRepository
+	scheduleRepo  *repository.ScheduleRepository
+	cursorRepo    *repository.EventCursorRepository
+	userClient    pb_user.UserServiceClient
+	converter     *exchange.Converter
+	// blobs stores uploaded images.
+	blobs blob.Store
+	// requireReview makes publishing wait for a moderator's approval.
+	requireReview bool
+	// cursorSecret signs listing cursors.
+	cursorSecret []byte
 }
 
-func NewProductService(productRepo *repository.ProductRepository) *ProductService {
+func NewProductService(productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, variantRepo *repository.VariantRepository, inventoryRepo *repository.InventoryRepository, imageRepo *repository.ImageRepository, priceRepo *repository.PriceRepository, scheduleRepo *repository.ScheduleRepository, cursorRepo *repository.EventCursorRepository, userClient pb_user.UserServiceClient, converter *exchange.Converter, blobs blob.Store, requireReview bool, cursorSecret []byte) *ProductService {
 	return &ProductService{
-		productRepo: productRepo,
+		productRepo:   productRepo,
+		categoryRepo:  categoryRepo,
+		variantRepo:   variantRepo,
+		inventoryRepo: inventoryRepo,
+		imageRepo:     imageRepo,
+		priceRepo:     priceRepo,
+		scheduleRepo:  scheduleRepo,
+		cursorRepo:    cursorRepo,
+		userClient:    userClient,
+		converter:     converter,
+		blobs:         blobs,
+		requireReview: requireReview,
+		cursorSecret:  cursorSecret,
+	}
+}
+
+// Owner is the public profile of a product's owner. It leaves out contact
+// details such as the email address, which buyers must not harvest.
+type Owner struct {
+	ID          uint64 `json:"id"`
+	MemberSince string `json:"member_since"`
+}
+
+// maxOwnerLookup is the most IDs the user service resolves in one call.
+const maxOwnerLookup = 500
+
+// GetOwners resolves the owners of the given products with as few calls to
+// the user service as its batch limit allows. Owners that no longer exist are
+// left out of the map.
+func (s *ProductService) GetOwners(ctx context.Context, products []*entity.Product) (map[uint64]*Owner, error) {
+	ids := make([]uint64, 0, len(products))
+	seen := make(map[uint64]bool, len(products))
+	for _, p := range products {
+		if !seen[p.UserID] {
+			seen[p.UserID] = true
+			ids = append(ids, p.UserID)
+		}
+	}
+
+	owners := make(map[uint64]*Owner, len(ids))
+	if len(ids) == 0 {
+		return owners, nil
 	}
+
+	for start := 0; start < len(ids); start += maxOwnerLookup {
+		end := start + maxOwnerLookup
+		if end > len(ids) {
+			end = len(ids)
+		}
+		resp, err := s.userClient.GetUsersByIDs(ctx, &pb_user.GetUsersByIDsRequest{Ids: ids[start:end]})
+		if err != nil {
+			return nil, err
+		}
+		for id, profile := range resp.Users {
+			owners[id] = &Owner{ID: profile.Id, MemberSince: profile.CreatedAt}
+		}
+	}
+
+	return owners, nil
+}
+
+// ProductInput holds the fields of a product its owner can edit.
+type ProductInput struct {
+	Name        string
+	Description string
+	Price       money.Money
+	// Tags replace the product's tags; nil leaves them unchanged on update.
+	Tags []string
+	// Attributes replace the product's attributes; nil leaves them unchanged
+	// on update.
+	Attributes entity.Attributes
+	// CategoryIDs assigns a new product to categories, whose schemas its
+	// attributes must satisfy. Updates change categories through
+	// SetProductCategories instead.
+	CategoryIDs []uint
 }
 
-func (s *ProductService) CreateProduct(ctx context.Context, name, description string, price float64, userID uint64) (*entity.Product, error) {
+func (s *ProductService) CreateProduct(ctx context.Context, input ProductInput, userID uint64, orgID *uint64) (*entity.Product, error) {
+	if orgID != nil {
+		if err := s.requireOrgRole(ctx, *orgID, userID, orgRoleMember); err != nil {
+			return nil, err
+		}
+	}
+
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validatePrice(input.Price); err != nil {
+		return nil, err
+	}
+
+	categoryIDs := uniqueIDs(input.CategoryIDs)
+	categories, err := s.categoryRepo.ListByIDs(ctx, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) != len(categoryIDs) {
+		return nil, repository.ErrCategoryNotFound
+	}
+	schema, err := s.categorySchema(ctx, categories)
+	if err != nil {
+		return nil, err
+	}
+	if input.Attributes == nil {
+		input.Attributes = entity.Attributes{}
+	}
+	if err := validateAttributes(input.Attributes, schema); err != nil {
+		return nil, err
+	}
+
 	product := &entity.Product{
-		Name:        name,
-		Description: description,
-		Price:       price,
+		Name:        input.Name,
+		Description: input.Description,
 		UserID:      userID,
+		OrgID:       orgID,
+		Tags:        tags,
+		Attributes:  input.Attributes,
+		Images:      []*entity.ProductImage{},
 	}
+	product.SetMoney(input.Price)
 
-	if err := s.productRepo.Create(ctx, product); err != nil {
+	if err := s.productRepo.Create(ctx, product, categoryIDs); err != nil {
 		return nil, err
 	}
 
 	return product, nil
 }
 
+func validatePrice(price money.Money) error {
+	if !money.ValidCurrency(price.Currency) {
+		return fmt.Errorf("%w: unknown currency %q", ErrInvalidPrice, price.Currency)
+	}
+	if price.Amount <= 0 {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidPrice)
+	}
+	return nil
+}
+
 func (s *ProductService) GetProduct(ctx context.Context, id uint64) (*entity.Product, error) {
-	return s.productRepo.GetByID(ctx, id)
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.productRepo.LoadTags(ctx, []*entity.Product{product}); err != nil {
+		return nil, err
+	}
+	if err := s.loadImages(ctx, []*entity.Product{product}); err != nil {
+		return nil, err
+	}
+	if err := s.loadPromotions(ctx, []*entity.Product{product}); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+const maxPerPage = 100
+
+// PageRequest selects a page of a listing. A cursor from a previous response
+// takes precedence over the page number.
+type PageRequest struct {
+	Page    int32
+	PerPage int32
+	Cursor  string
+	// SkipCount leaves out the total, saving a count over all matches.
+	SkipCount bool
+	// Facets also aggregates tag, category and price counts over all matches.
+	Facets bool
+}
+
+// ProductPage is a page of a listing. Total is -1 when it was not counted.
+type ProductPage struct {
+	Products   []*entity.Product
+	Total      int64
+	Page       int32
+	PerPage    int32
+	NextCursor string
+	PrevCursor string
+	Facets     *repository.Facets
+}
+
+// ListProducts returns a page of visible, published products matching the
+// filter. Query results default to relevance order, everything else to ID
+// order.
+func (s *ProductService) ListProducts(ctx context.Context, filter repository.ProductFilter, req PageRequest) (*ProductPage, error) {
+	filter.Status = entity.StatusPublished
+	if filter.SortBy == "" {
+		filter.SortBy = "id"
+		if filter.Query != "" {
+			filter.SortBy = "relevance"
+		}
+	}
+	if !repository.ProductSortColumns[filter.SortBy] {
+		return nil, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidFilter, filter.SortBy)
+	}
+	if filter.SortBy == "relevance" {
+		if filter.Query == "" {
+			return nil, fmt.Errorf("%w: sorting by relevance requires a query", ErrInvalidFilter)
+		}
+		// Best matches always come first
+		filter.Descending = true
+	}
+	if filter.MinPrice < 0 || filter.MaxPrice < 0 || (filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice) {
+		return nil, fmt.Errorf("%w: invalid price range", ErrInvalidFilter)
+	}
+	// Price bounds are only meaningful within one currency
+	if (filter.MinPrice > 0 || filter.MaxPrice > 0) && filter.Currency == "" {
+		filter.Currency = money.DefaultCurrency
+	}
+
+	if filter.Category != "" {
+		var err error
+		if filter.CategoryPath, err = s.categoryPath(ctx, filter.Category); err != nil {
+			return nil, err
+		}
+	}
+	if len(filter.Tags) > 0 {
+		var err error
+		if filter.Tags, err = normalizeTags(filter.Tags); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+		}
+	}
+	if err := validateAttributeFilter(filter.Attributes); err != nil {
+		return nil, err
+	}
+
+	page, err := s.list(ctx, filter, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Facets {
+		currency := filter.Currency
+		if currency == "" {
+			currency = money.DefaultCurrency
+		}
+		if page.Facets, err = s.productRepo.Facets(ctx, filter, currency); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
 }
 
-func (s *ProductService) ListProducts(ctx context.Context, page, perPage int32) ([]*entity.Product, int64, error) {
-	return s.productRepo.List(ctx, page, perPage)
+// ListUserProducts returns the user's own products in any status, or only
+// those in the given one, including products hidden from public listings.
+func (s *ProductService) ListUserProducts(ctx context.Context, userID uint64, status string, req PageRequest) (*ProductPage, error) {
+	if err := validateStatus(status); err != nil {
+		return nil, err
+	}
+	return s.list(ctx, repository.ProductFilter{
+		OwnerID:       userID,
+		Status:        status,
+		IncludeHidden: true,
+		SortBy:        "id",
+	}, req)
+}
+
+func (s *ProductService) list(ctx context.Context, filter repository.ProductFilter, req PageRequest) (*ProductPage, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PerPage < 1 {
+		req.PerPage = 10
+	}
+	if req.PerPage > maxPerPage {
+		req.PerPage = maxPerPage
+	}
+
+	// Fetch one extra row to learn whether the listing continues
+	page := repository.ProductPage{
+		Limit:     int(req.PerPage) + 1,
+		SkipCount: req.SkipCount,
+	}
+	if req.Cursor != "" {
+		cursor, err := s.decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortBy != filter.SortBy || cursor.Descending != filter.Descending {
+			return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+		}
+		page.Cursor = &repository.ProductCursor{Value: cursor.Value, ID: cursor.ID}
+		page.Backward = cursor.Backward
+	} else {
+		page.Offset = int((req.Page - 1) * req.PerPage)
+	}
+
+	products, total, err := s.productRepo.List(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.productRepo.LoadTags(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := s.loadImages(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := s.loadPromotions(ctx, products); err != nil {
+		return nil, err
+	}
+
+	hasMore := len(products) > int(req.PerPage)
+	if hasMore {
+		if page.Backward {
+			products = products[1:]
+		} else {
+			products = products[:req.PerPage]
+		}
+	}
+
+	result := &ProductPage{
+		Products: products,
+		Total:    total,
+		Page:     req.Page,
+		PerPage:  req.PerPage,
+	}
+	if len(products) == 0 {
+		return result, nil
+	}
+
+	first := repository.CursorFor(products[0], filter.SortBy)
+	last := repository.CursorFor(products[len(products)-1], filter.SortBy)
+	if page.Backward {
+		// We came from the following page, so it exists
+		result.NextCursor = s.cursorFor(filter, last, false)
+		if hasMore {
+			result.PrevCursor = s.cursorFor(filter, first, true)
+		}
+	} else {
+		if hasMore {
+			result.NextCursor = s.cursorFor(filter, last, false)
+		}
+		if page.Cursor != nil || page.Offset > 0 {
+			result.PrevCursor = s.cursorFor(filter, first, true)
+		}
+	}
+
+	return result, nil
 }
 
-func (s *ProductService) UpdateProduct(ctx context.Context, id uint64, userID uint64, name, description string, price float64) (*entity.Product, error) {
-	// Check if product belongs to user
-	belongs, err := s.productRepo.BelongsToUser(ctx, id, userID)
+func (s *ProductService) UpdateProduct(ctx context.Context, id uint64, userID uint64, input ProductInput) (*entity.Product, error) {
+	if err := s.authorize(ctx, id, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+
+	tags, err := normalizeTags(input.Tags)
 	if err != nil {
 		return nil, err
 	}
-	if !belongs {
-		return nil, errors.New("product does not belong to user")
+
+	if err := validatePrice(input.Price); err != nil {
+		return nil, err
+	}
+
+	if input.Attributes != nil {
+		schema, err := s.productSchema(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateAttributes(input.Attributes, schema); err != nil {
+			return nil, err
+		}
+	}
+
+	current, err := s.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
 	product := &entity.Product{
-		Name:        name,
-		Description: description,
-		Price:       price,
+		Name:        input.Name,
+		Description: input.Description,
+		Tags:        tags,
+		Attributes:  input.Attributes,
 	}
+	product.SetMoney(input.Price)
 
-	if err := s.productRepo.Update(ctx, id, product); err != nil {
+	// With moderation, changed content of a published product has to be
+	// approved again before it is listed
+	resubmit := s.requireReview && contentChanged(current, product)
+	if err := s.productRepo.Update(ctx, id, product, userID, resubmit); err != nil {
 		return nil, err
 	}
 
-	return s.productRepo.GetByID(ctx, id)
+	return s.GetProduct(ctx, id)
 }
 
+// DeleteProduct moves the product to the trash, from where it can be
+// restored until it is purged. Its pending reservations are released and its
+// scheduled actions cancelled. The SKUs of its variants stay taken while it is
+// in the trash, so that it can be restored intact; purge it to reuse them.
 func (s *ProductService) DeleteProduct(ctx context.Context, id uint64, userID uint64) error {
-	// Check if product belongs to user
-	belongs, err := s.productRepo.BelongsToUser(ctx, id, userID)
+	if err := s.authorize(ctx, id, userID, orgRoleAdmin); err != nil {
+		return err
+	}
+	return s.productRepo.Delete(ctx, id, userID)
+}
+
+// DeleteUserProducts removes the products the user owns personally for good,
+// including those in the trash. Organization products outlive their creator.
+func (s *ProductService) DeleteUserProducts(ctx context.Context, userID uint64) (int64, error) {
+	images, err := s.imageRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := s.productRepo.DeleteByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.deleteImageBlobs(ctx, images)
+	return deleted, nil
+}
+
+var (
+	ErrForbidden       = errors.New("product does not belong to user")
+	ErrInvalidFilter   = errors.New("invalid filter")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrInvalidTag      = errors.New("invalid tag")
+	ErrInvalidPrice    = errors.New("invalid price")
+	ErrInvalidCurrency = errors.New("invalid currency")
+)
+
+const (
+	orgRoleOwner  = "owner"
+	orgRoleAdmin  = "admin"
+	orgRoleMember = "member"
+)
+
+func orgRoleRank(role string) int {
+	switch role {
+	case orgRoleOwner:
+		return 3
+	case orgRoleAdmin:
+		return 2
+	case orgRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// authorize checks that the user may modify the product. Personal products
+// are only accessible to their owner; organization products to members
+// holding at least minRole.
+func (s *ProductService) authorize(ctx context.Context, productID, userID uint64, minRole string) error {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrForbidden
+	}
 	if err != nil {
 		return err
 	}
-	if !belongs {
-		return errors.New("product does not belong to user")
+	return s.authorizeProduct(ctx, product, userID, minRole)
+}
+
+func (s *ProductService) authorizeProduct(ctx context.Context, product *entity.Product, userID uint64, minRole string) error {
+	if product.OrgID == nil {
+		if product.UserID != userID {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	return s.requireOrgRole(ctx, *product.OrgID, userID, minRole)
+}
+
+// orgsWithRole returns the organizations in which the user has at least
+// minRole.
+func (s *ProductService) orgsWithRole(ctx context.Context, userID uint64, minRole string) ([]uint64, error) {
+	resp, err := s.userClient.ListUserOrganizations(ctx, &pb_user.ListUserOrganizationsRequest{UserId: userID})
+	if err != nil {
+		return nil, err
 	}
 
-	return s.productRepo.Delete(ctx, id)
+	var orgIDs []uint64
+	for _, org := range resp.Organizations {
+		if orgRoleRank(org.Role) >= orgRoleRank(minRole) {
+			orgIDs = append(orgIDs, org.Organization.Id)
+		}
+	}
+	return orgIDs, nil
 }
 
-func (s *ProductService) ListUserProducts(ctx context.Context, userID uint64, page, perPage int32) ([]*entity.Product, int64, error) {
-	return s.productRepo.ListByUserID(ctx, userID, page, perPage)
+func (s *ProductService) requireOrgRole(ctx context.Context, orgID, userID uint64, minRole string) error {
+	member, err := s.userClient.GetMembership(ctx, &pb_user.GetMembershipRequest{
+		OrganizationId: orgID,
+		UserId:         userID,
+	})
+	if status.Code(err) == codes.NotFound {
+		return ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+
+	if orgRoleRank(member.Role) < orgRoleRank(minRole) {
+		return ErrForbidden
+	}
+	return nil
 }
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	pb_user "github.com/gauss2302/testcommm/product/proto/user"
+)
+
+// fakeUsers answers the user service calls authorization makes from fixed
+// roles. Other calls panic.
+type fakeUsers struct {
+	pb_user.UserServiceClient
+	// roles maps an organization and a user to the user's role in it.
+	roles map[[2]uint64]string
+	// admins are the platform admins.
+	admins map[uint64]bool
+}
+
+func (f *fakeUsers) GetMembership(ctx context.Context, in *pb_user.GetMembershipRequest, opts ...grpc.CallOption) (*pb_user.Member, error) {
+	role, ok := f.roles[[2]uint64{in.OrganizationId, in.UserId}]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not a member")
+	}
+	return &pb_user.Member{OrganizationId: in.OrganizationId, UserId: in.UserId, Role: role}, nil
+}
+
+func (f *fakeUsers) ListUserOrganizations(ctx context.Context, in *pb_user.ListUserOrganizationsRequest, opts ...grpc.CallOption) (*pb_user.ListUserOrganizationsResponse, error) {
+	resp := &pb_user.ListUserOrganizationsResponse{}
+	// Ascending organization IDs keep results comparable
+	for orgID := uint64(1); orgID <= 10; orgID++ {
+		if role, ok := f.roles[[2]uint64{orgID, in.UserId}]; ok {
+			resp.Organizations = append(resp.Organizations, &pb_user.UserOrganization{
+				Organization: &pb_user.Organization{Id: orgID},
+				Role:         role,
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeUsers) GetUserByID(ctx context.Context, in *pb_user.GetUserByIDRequest, opts ...grpc.CallOption) (*pb_user.User, error) {
+	role := "user"
+	if f.admins[in.Id] {
+		role = roleAdmin
+	}
+	return &pb_user.User{Id: in.Id, Role: role}, nil
+}
+
+func testUsers() *fakeUsers {
+	return &fakeUsers{
+		roles: map[[2]uint64]string{
+			{1, 10}: orgRoleOwner,
+			{1, 11}: orgRoleAdmin,
+			{1, 12}: orgRoleMember,
+			{2, 11}: orgRoleMember,
+			{3, 11}: orgRoleOwner,
+			{2, 12}: "unknown",
+		},
+		admins: map[uint64]bool{99: true},
+	}
+}
+
+func TestOrgsWithRole(t *testing.T) {
+	s := &ProductService{userClient: testUsers()}
+
+	tests := []struct {
+		userID  uint64
+		minRole string
+		want    []uint64
+	}{
+		{10, orgRoleAdmin, []uint64{1}},
+		{11, orgRoleAdmin, []uint64{1, 3}},
+		{11, orgRoleMember, []uint64{1, 2, 3}},
+		{11, orgRoleOwner, []uint64{3}},
+		{12, orgRoleAdmin, nil},
+		{12, orgRoleMember, []uint64{1}},
+		{13, orgRoleMember, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := s.orgsWithRole(context.Background(), tt.userID, tt.minRole)
+		if err != nil {
+			t.Errorf("orgsWithRole(%d, %s): %v", tt.userID, tt.minRole, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("orgsWithRole(%d, %s) = %v, want %v", tt.userID, tt.minRole, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizeProduct(t *testing.T) {
+	s := &ProductService{userClient: testUsers()}
+	org := func(id uint64) *uint64 { return &id }
+
+	tests := []struct {
+		name    string
+		product *entity.Product
+		userID  uint64
+		minRole string
+		wantErr error
+	}{
+		{"personal owner", &entity.Product{UserID: 10}, 10, orgRoleMember, nil},
+		{"personal stranger", &entity.Product{UserID: 10}, 11, orgRoleMember, ErrForbidden},
+		{"personal owner with an org role elsewhere", &entity.Product{UserID: 12}, 12, orgRoleOwner, nil},
+		{"org owner", &entity.Product{UserID: 12, OrgID: org(1)}, 10, orgRoleAdmin, nil},
+		{"org admin", &entity.Product{UserID: 12, OrgID: org(1)}, 11, orgRoleAdmin, nil},
+		{"org member below admin", &entity.Product{UserID: 12, OrgID: org(1)}, 12, orgRoleAdmin, ErrForbidden},
+		{"org member", &entity.Product{UserID: 10, OrgID: org(1)}, 12, orgRoleMember, nil},
+		{"org admin below owner", &entity.Product{UserID: 10, OrgID: org(1)}, 11, orgRoleOwner, ErrForbidden},
+		{"creator who left the org", &entity.Product{UserID: 13, OrgID: org(1)}, 13, orgRoleMember, ErrForbidden},
+		{"owner of another org", &entity.Product{UserID: 11, OrgID: org(2)}, 10, orgRoleMember, ErrForbidden},
+		{"unknown role", &entity.Product{UserID: 11, OrgID: org(2)}, 12, orgRoleMember, ErrForbidden},
+		{"platform admin is not an org member", &entity.Product{UserID: 10, OrgID: org(1)}, 99, orgRoleMember, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		err := s.authorizeProduct(context.Background(), tt.product, tt.userID, tt.minRole)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: authorizeProduct(user %d, %s) = %v, want %v", tt.name, tt.userID, tt.minRole, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	s := &ProductService{userClient: testUsers()}
+
+	tests := []struct {
+		userID  uint64
+		wantErr error
+	}{
+		{99, nil},
+		{10, ErrNotAdmin},
+		{11, ErrNotAdmin},
+	}
+
+	for _, tt := range tests {
+		if err := s.requireAdmin(context.Background(), tt.userID); !errors.Is(err, tt.wantErr) {
+			t.Errorf("requireAdmin(%d) = %v, want %v", tt.userID, err, tt.wantErr)
+		}
+	}
+}
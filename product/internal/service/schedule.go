@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+const (
+	maxPendingSchedules = 50
+	// scheduleBatchSize bounds the actions one run of the scheduler executes.
+	scheduleBatchSize = 100
+)
+
+var scheduleStatuses = map[string]bool{
+	entity.SchedulePending:   true,
+	entity.ScheduleDone:      true,
+	entity.ScheduleFailed:    true,
+	entity.ScheduleCancelled: true,
+}
+
+// ScheduleInput describes an action to run on a product later.
+type ScheduleInput struct {
+	Action string
+	RunAt  time.Time
+	// Price is the new price of a price change.
+	Price *money.Money
+	// RevertAt optionally restores the previous price after a price change.
+	RevertAt *time.Time
+}
+
+// ScheduleAction schedules a publish, unpublish or price change of the
+// product. The action runs on behalf of the user, who must still be allowed
+// to manage the product by then. With moderation enabled, a scheduled publish
+// submits the product for review.
+func (s *ProductService) ScheduleAction(ctx context.Context, productID, userID uint64, input ScheduleInput) (*entity.ScheduledAction, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	action, err := newScheduledAction(productID, userID, input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.scheduleRepo.CountPending(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxPendingSchedules {
+		return nil, fmt.Errorf("%w: a product can have at most %d pending actions", ErrInvalidSchedule, maxPendingSchedules)
+	}
+
+	if err := s.scheduleRepo.Create(ctx, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// newScheduledAction validates the input and returns the pending action it
+// describes.
+func newScheduledAction(productID, userID uint64, input ScheduleInput, now time.Time) (*entity.ScheduledAction, error) {
+	action := &entity.ScheduledAction{
+		ProductID: uint(productID),
+		Action:    input.Action,
+		RunAt:     input.RunAt,
+		Status:    entity.SchedulePending,
+		CreatedBy: userID,
+	}
+	switch input.Action {
+	case entity.SchedulePublish, entity.ScheduleUnpublish:
+		if input.Price != nil || input.RevertAt != nil {
+			return nil, fmt.Errorf("%w: only price changes take a price", ErrInvalidSchedule)
+		}
+	case entity.SchedulePrice:
+		if input.Price == nil {
+			return nil, fmt.Errorf("%w: a price change needs a price", ErrInvalidSchedule)
+		}
+		if err := validatePrice(*input.Price); err != nil {
+			return nil, err
+		}
+		if input.RevertAt != nil && !input.RevertAt.After(input.RunAt) {
+			return nil, fmt.Errorf("%w: revert_at must be after run_at", ErrInvalidSchedule)
+		}
+		action.PriceMinor = &input.Price.Amount
+		action.Currency = input.Price.Currency
+		action.RevertAt = input.RevertAt
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidSchedule, input.Action)
+	}
+	if !input.RunAt.After(now) {
+		return nil, fmt.Errorf("%w: run_at must be in the future", ErrInvalidSchedule)
+	}
+	return action, nil
+}
+
+// ListSchedules returns the scheduled actions of the product, optionally only
+// those in one status. Only the product's sellers can see them.
+func (s *ProductService) ListSchedules(ctx context.Context, productID, userID uint64, status string) ([]*entity.ScheduledAction, error) {
+	if status != "" && !scheduleStatuses[status] {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, status)
+	}
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepo.ListByProductID(ctx, productID, status)
+}
+
+// CancelSchedule stops a pending action from running.
+func (s *ProductService) CancelSchedule(ctx context.Context, productID, id, userID uint64) (*entity.ScheduledAction, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepo.Cancel(ctx, productID, id)
+}
+
+// RunSchedules executes due actions every interval until ctx is cancelled,
+// and records promotions that started or ended in the price history.
+// Only the replica holding the leader lock runs them, and each action is
+// claimed and applied in one transaction, so each action runs once.
+func (s *ProductService) RunSchedules(ctx context.Context, leader *repository.LeaderLock, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer leader.Release()
+
+	leading := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := leader.Acquire(ctx)
+		if err != nil {
+			log.Printf("Failed to acquire scheduler lock: %v", err)
+		}
+		if ok != leading {
+			leading = ok
+			if leading {
+				log.Printf("Running scheduled actions on this replica")
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		for {
+			n, err := s.runDueActions(ctx)
+			if err != nil {
+				log.Printf("Failed to run scheduled actions: %v", err)
+				break
+			}
+			if n < scheduleBatchSize {
+				break
+			}
+		}
+		if err := s.recordPromotions(ctx); err != nil {
+			log.Printf("Failed to record promotions in the price history: %v", err)
+		}
+	}
+}
+
+// runDueActions executes up to a batch of due actions and returns how many
+// ran.
+func (s *ProductService) runDueActions(ctx context.Context) (int, error) {
+	plan := func(action *entity.ScheduledAction) (repository.ScheduledChange, error) {
+		return s.planAction(ctx, action)
+	}
+
+	for n := 0; n < scheduleBatchSize; n++ {
+		action, err := s.scheduleRepo.RunDue(ctx, time.Now(), plan)
+		if err != nil {
+			return n, err
+		}
+		if action == nil {
+			return n, nil
+		}
+		if action.Status == entity.ScheduleFailed {
+			log.Printf("Scheduled %s of product %d failed: %s", action.Action, action.ProductID, action.Error)
+		}
+	}
+	return scheduleBatchSize, nil
+}
+
+// planAction checks that the user who scheduled an action may still manage
+// the product and returns the change the action makes.
+func (s *ProductService) planAction(ctx context.Context, action *entity.ScheduledAction) (repository.ScheduledChange, error) {
+	if err := s.authorize(ctx, uint64(action.ProductID), action.CreatedBy, orgRoleMember); err != nil {
+		return repository.ScheduledChange{}, err
+	}
+	return s.scheduledChange(action)
+}
+
+// scheduledChange returns the change an action makes. Scheduled publishes
+// and unpublishes follow the same transitions as the seller's own.
+func (s *ProductService) scheduledChange(action *entity.ScheduledAction) (repository.ScheduledChange, error) {
+	switch action.Action {
+	case entity.SchedulePublish:
+		return repository.ScheduledChange{From: publishableFrom, Status: s.publishStatus()}, nil
+	case entity.ScheduleUnpublish:
+		return repository.ScheduledChange{From: unpublishableFrom, Status: entity.StatusDraft}, nil
+	case entity.SchedulePrice, entity.SchedulePriceRevert:
+		return repository.ScheduledChange{Price: &money.Money{Amount: *action.PriceMinor, Currency: action.Currency}}, nil
+	}
+	return repository.ScheduledChange{}, fmt.Errorf("%w: unknown action %q", ErrInvalidSchedule, action.Action)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+func TestNewScheduledAction(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	later, muchLater := now.Add(time.Hour), now.Add(48*time.Hour)
+	price := &money.Money{Amount: 900, Currency: "USD"}
+
+	tests := []struct {
+		name    string
+		input   ScheduleInput
+		wantErr error
+	}{
+		{"publish", ScheduleInput{Action: entity.SchedulePublish, RunAt: later}, nil},
+		{"unpublish", ScheduleInput{Action: entity.ScheduleUnpublish, RunAt: later}, nil},
+		{"price change", ScheduleInput{Action: entity.SchedulePrice, RunAt: later, Price: price}, nil},
+		{"price change with revert", ScheduleInput{Action: entity.SchedulePrice, RunAt: later, Price: price, RevertAt: &muchLater}, nil},
+		{"run now", ScheduleInput{Action: entity.SchedulePublish, RunAt: now}, ErrInvalidSchedule},
+		{"run in the past", ScheduleInput{Action: entity.SchedulePublish, RunAt: now.Add(-time.Minute)}, ErrInvalidSchedule},
+		{"publish with price", ScheduleInput{Action: entity.SchedulePublish, RunAt: later, Price: price}, ErrInvalidSchedule},
+		{"unpublish with revert", ScheduleInput{Action: entity.ScheduleUnpublish, RunAt: later, RevertAt: &muchLater}, ErrInvalidSchedule},
+		{"price change without price", ScheduleInput{Action: entity.SchedulePrice, RunAt: later}, ErrInvalidSchedule},
+		{"price change to zero", ScheduleInput{Action: entity.SchedulePrice, RunAt: later, Price: &money.Money{Currency: "USD"}}, ErrInvalidPrice},
+		{"revert at run time", ScheduleInput{Action: entity.SchedulePrice, RunAt: later, Price: price, RevertAt: &later}, ErrInvalidSchedule},
+		{"revert before run time", ScheduleInput{Action: entity.SchedulePrice, RunAt: muchLater, Price: price, RevertAt: &later}, ErrInvalidSchedule},
+		{"revert scheduled directly", ScheduleInput{Action: entity.SchedulePriceRevert, RunAt: later, Price: price}, ErrInvalidSchedule},
+		{"unknown action", ScheduleInput{Action: "delete", RunAt: later}, ErrInvalidSchedule},
+	}
+
+	for _, tt := range tests {
+		action, err := newScheduledAction(1, 10, tt.input, now)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: newScheduledAction error = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if action.Status != entity.SchedulePending || action.CreatedBy != 10 || !action.RunAt.Equal(tt.input.RunAt) {
+			t.Errorf("%s: newScheduledAction = %+v", tt.name, action)
+		}
+		if (action.PriceMinor != nil) != (tt.input.Price != nil) || action.RevertAt != tt.input.RevertAt {
+			t.Errorf("%s: price %v, revert at %v; want %v, %v", tt.name, action.PriceMinor, action.RevertAt, tt.input.Price, tt.input.RevertAt)
+		}
+	}
+}
+
+func TestScheduledChange(t *testing.T) {
+	amount := int64(900)
+	price := &money.Money{Amount: 900, Currency: "USD"}
+
+	tests := []struct {
+		name          string
+		requireReview bool
+		action        *entity.ScheduledAction
+		want          repository.ScheduledChange
+		wantErr       error
+	}{
+		{
+			name:   "publish",
+			action: &entity.ScheduledAction{Action: entity.SchedulePublish},
+			want:   repository.ScheduledChange{From: publishableFrom, Status: entity.StatusPublished},
+		},
+		{
+			name:          "publish with moderation",
+			requireReview: true,
+			action:        &entity.ScheduledAction{Action: entity.SchedulePublish},
+			want:          repository.ScheduledChange{From: publishableFrom, Status: entity.StatusPendingReview},
+		},
+		{
+			name:   "unpublish",
+			action: &entity.ScheduledAction{Action: entity.ScheduleUnpublish},
+			want:   repository.ScheduledChange{From: unpublishableFrom, Status: entity.StatusDraft},
+		},
+		{
+			name:   "price change",
+			action: &entity.ScheduledAction{Action: entity.SchedulePrice, PriceMinor: &amount, Currency: "USD"},
+			want:   repository.ScheduledChange{Price: price},
+		},
+		{
+			name:   "price revert",
+			action: &entity.ScheduledAction{Action: entity.SchedulePriceRevert, PriceMinor: &amount, Currency: "USD"},
+			want:   repository.ScheduledChange{Price: price},
+		},
+		{
+			name:    "unknown action",
+			action:  &entity.ScheduledAction{Action: "delete"},
+			wantErr: ErrInvalidSchedule,
+		},
+	}
+
+	for _, tt := range tests {
+		s := &ProductService{requireReview: tt.requireReview}
+		got, err := s.scheduledChange(tt.action)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: scheduledChange error = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: scheduledChange = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
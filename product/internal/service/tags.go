@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	maxTags      = 20
+	maxTagLength = 50
+)
+
+var (
+	tagPattern    = regexp.MustCompile(`^[\p{L}\p{N}]+(-[\p{L}\p{N}]+)*$`)
+	tagWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// normalizeTags lowercases tags, joins words with dashes and drops
+// duplicates. A nil slice stays nil so updates can leave tags unchanged.
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = tagWhitespace.ReplaceAllString(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength || !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	if len(result) > maxTags {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTag, maxTags)
+	}
+	return result, nil
+}
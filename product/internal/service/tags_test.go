@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, maxTags+1)
+	for i := range tooMany {
+		tooMany[i] = "tag" + strings.Repeat("x", i)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+		err  error
+	}{
+		{"nil stays nil", nil, nil, nil},
+		{"empty clears", []string{}, []string{}, nil},
+		{"lowercased", []string{"Summer", "SALE"}, []string{"summer", "sale"}, nil},
+		{"words joined", []string{"  Hand   Made\t"}, []string{"hand-made"}, nil},
+		{"duplicates dropped", []string{"eco", "Eco", " eco "}, []string{"eco"}, nil},
+		{"blank skipped", []string{"", "  ", "new"}, []string{"new"}, nil},
+		{"unicode letters", []string{"Кожа", "日本"}, []string{"кожа", "日本"}, nil},
+		{"dashes kept", []string{"t-shirt"}, []string{"t-shirt"}, nil},
+		{"leading dash", []string{"-sale"}, nil, ErrInvalidTag},
+		{"double dash", []string{"t--shirt"}, nil, ErrInvalidTag},
+		{"punctuation", []string{"50%off"}, nil, ErrInvalidTag},
+		{"too long", []string{strings.Repeat("a", maxTagLength+1)}, nil, ErrInvalidTag},
+		{"longest allowed", []string{strings.Repeat("a", maxTagLength)}, []string{strings.Repeat("a", maxTagLength)}, nil},
+		{"too many", tooMany, nil, ErrInvalidTag},
+		{"duplicates not counted", append(tooMany[:maxTags:maxTags], "tag"), tooMany[:maxTags], nil},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeTags(tt.tags)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: normalizeTags(%q) error = %v, want %v", tt.name, tt.tags, err, tt.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: normalizeTags(%q) = %q, want %q", tt.name, tt.tags, got, tt.want)
+		}
+	}
+}
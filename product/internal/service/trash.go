@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+const (
+	// DefaultTrashRetention is how long deleted products can be restored.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+	// purgeBatchSize bounds the products one transaction purges.
+	purgeBatchSize = 100
+)
+
+// ListTrash returns the deleted products the user may restore: their
+// personal ones and those of the organizations they administer.
+func (s *ProductService) ListTrash(ctx context.Context, userID uint64, req PageRequest) (*ProductPage, error) {
+	orgIDs, err := s.orgsWithRole(ctx, userID, orgRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	return s.list(ctx, repository.ProductFilter{
+		ManagedBy:     userID,
+		ManagedOrgs:   orgIDs,
+		Deleted:       true,
+		IncludeHidden: true,
+		SortBy:        "id",
+	}, req)
+}
+
+// RestoreProduct takes a product out of the trash. Those who may delete a
+// product may restore it.
+func (s *ProductService) RestoreProduct(ctx context.Context, id, userID uint64) (*entity.Product, error) {
+	product, err := s.productRepo.GetDeletedByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeProduct(ctx, product, userID, orgRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if err := s.productRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.GetProduct(ctx, id)
+}
+
+// PurgeProduct deletes a product for good, whether or not it is in the
+// trash. Only admins can.
+func (s *ProductService) PurgeProduct(ctx context.Context, id, adminID uint64) error {
+	if err := s.requireAdmin(ctx, adminID); err != nil {
+		return err
+	}
+
+	images, err := s.imageRepo.ListByProductIDs(ctx, []uint{uint(id)})
+	if err != nil {
+		return err
+	}
+	if err := s.productRepo.HardDelete(ctx, id); err != nil {
+		return err
+	}
+	s.deleteImageBlobs(ctx, images)
+	return nil
+}
+
+// PurgeTrash periodically deletes products that have been in the trash for
+// longer than retention for good, until ctx is cancelled.
+func (s *ProductService) PurgeTrash(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for {
+			n, images, err := s.productRepo.PurgeDeleted(ctx, time.Now().Add(-retention), purgeBatchSize)
+			if err != nil {
+				log.Printf("Failed to purge deleted products: %v", err)
+				break
+			}
+			s.deleteImageBlobs(ctx, images)
+			if n > 0 {
+				log.Printf("Purged %d deleted products", n)
+			}
+			if n < purgeBatchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb_user "github.com/gauss2302/testcommm/product/proto/user"
+)
+
+const userEventsCursor = "user_events"
+
+// ConsumeUserEvents follows the user service event stream, hides the
+// listings of sellers whose accounts are suspended, banned or deleted and
+// removes the products of purged accounts. The cursor is stored in Postgres
+// so the consumer resumes after a restart.
+func (s *ProductService) ConsumeUserEvents(ctx context.Context) {
+	for {
+		err := s.consumeUserEvents(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("User event stream interrupted: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (s *ProductService) consumeUserEvents(ctx context.Context) error {
+	cursor, err := s.cursorRepo.Get(ctx, userEventsCursor)
+	if err != nil {
+		return err
+	}
+
+	stream, err := s.userClient.WatchUserEvents(ctx, &pb_user.WatchUserEventsRequest{
+		Cursor:        cursor,
+		StartAtLatest: cursor == "",
+		Types:         []string{"user.status_changed", "user.deleted", "user.purged"},
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			return errors.New("stream closed")
+		}
+		if status.Code(err) == codes.OutOfRange {
+			// The events after our cursor were pruned; skip to the latest
+			// rather than retry forever
+			log.Printf("Missed pruned user events, resuming from the latest: %v", err)
+			if err := s.cursorRepo.Save(ctx, userEventsCursor, ""); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.handleUserEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to handle event %s: %v", event.Cursor, err)
+		}
+		if err := s.cursorRepo.Save(ctx, userEventsCursor, event.Cursor); err != nil {
+			return err
+		}
+	}
+}
+
+// handleUserEvent hides or shows the listings of a seller whose status
+// changed, hides them while a deleted account waits to be purged, and removes
+// the personal products of a purged account for good. Every step is
+// idempotent, so an event handled twice does no harm.
+func (s *ProductService) handleUserEvent(ctx context.Context, event *pb_user.UserEvent) error {
+	switch sellerActionFor(event) {
+	case removeProducts:
+		deleted, err := s.DeleteUserProducts(ctx, event.UserId)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			log.Printf("Removed %d products of purged user %d", deleted, event.UserId)
+		}
+		return nil
+	case hideProducts:
+		return s.productRepo.SetOwnerHidden(ctx, event.UserId, true)
+	}
+	return s.productRepo.SetOwnerHidden(ctx, event.UserId, false)
+}
+
+// sellerAction is what a user event means for the seller's products.
+type sellerAction int
+
+const (
+	showProducts sellerAction = iota
+	hideProducts
+	removeProducts
+)
+
+// sellerActionFor maps a user event to a seller action. A deleted account
+// stays hidden whatever status it had when it was deleted.
+func sellerActionFor(event *pb_user.UserEvent) sellerAction {
+	switch {
+	case event.Type == "user.purged":
+		return removeProducts
+	case event.Type == "user.deleted", event.User.GetStatus() != "active":
+		return hideProducts
+	}
+	return showProducts
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+
+	pb_user "github.com/gauss2302/testcommm/product/proto/user"
+)
+
+func TestSellerActionFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *pb_user.UserEvent
+		want  sellerAction
+	}{
+		{"reactivated", &pb_user.UserEvent{Type: "user.status_changed", User: &pb_user.User{Status: "active"}}, showProducts},
+		{"suspended", &pb_user.UserEvent{Type: "user.status_changed", User: &pb_user.User{Status: "suspended"}}, hideProducts},
+		{"banned", &pb_user.UserEvent{Type: "user.status_changed", User: &pb_user.User{Status: "banned"}}, hideProducts},
+		{"pending", &pb_user.UserEvent{Type: "user.status_changed", User: &pb_user.User{Status: "pending"}}, hideProducts},
+		{"deleted while active", &pb_user.UserEvent{Type: "user.deleted", User: &pb_user.User{Status: "active"}}, hideProducts},
+		{"deleted without snapshot", &pb_user.UserEvent{Type: "user.deleted"}, hideProducts},
+		{"purged", &pb_user.UserEvent{Type: "user.purged", User: &pb_user.User{Status: "active"}}, removeProducts},
+		{"purged without snapshot", &pb_user.UserEvent{Type: "user.purged"}, removeProducts},
+	}
+
+	for _, tt := range tests {
+		if got := sellerActionFor(tt.event); got != tt.want {
+			t.Errorf("%s: sellerActionFor = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+	"github.com/gauss2302/testcommm/product/internal/repository"
+)
+
+var ErrInvalidVariant = errors.New("invalid variant")
+
+const (
+	maxVariantOptions = 10
+	maxOptionLength   = 50
+)
+
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// VariantInput holds the fields of a variant its seller can edit.
+type VariantInput struct {
+	SKU     string
+	Options map[string]string
+	// PriceOverride replaces the product price; it must be in the product's
+	// currency.
+	PriceOverride *money.Money
+	// Stock sets the stock on hand through the inventory; nil leaves it
+	// unchanged.
+	Stock *int
+}
+
+func (s *ProductService) ListVariants(ctx context.Context, productID, userID uint64) ([]*entity.Variant, error) {
+	product, err := s.visibleProduct(ctx, productID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadPromotions(ctx, []*entity.Product{product}); err != nil {
+		return nil, err
+	}
+
+	variants, err := s.variantRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		setVariantPrice(variant, product)
+	}
+	if err := s.loadVariantStock(ctx, productID, variants); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (s *ProductService) GetVariant(ctx context.Context, productID, id, userID uint64) (*entity.Variant, error) {
+	product, err := s.visibleProduct(ctx, productID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadPromotions(ctx, []*entity.Product{product}); err != nil {
+		return nil, err
+	}
+
+	variant, err := s.variantRepo.Get(ctx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+	setVariantPrice(variant, product)
+	if err := s.loadVariantStock(ctx, productID, []*entity.Variant{variant}); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+func (s *ProductService) CreateVariant(ctx context.Context, productID, userID uint64, input VariantInput) (*entity.Variant, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateVariant(&input, product); err != nil {
+		return nil, err
+	}
+
+	variant := &entity.Variant{
+		ProductID: product.ID,
+		SKU:       input.SKU,
+		Options:   input.Options,
+	}
+	if input.PriceOverride != nil {
+		variant.PriceOverrideMinor = &input.PriceOverride.Amount
+	}
+
+	if err := s.variantRepo.Create(ctx, variant, overrideCurrency(input)); err != nil {
+		return nil, err
+	}
+	if err := s.resubmit(ctx, productID); err != nil {
+		return nil, err
+	}
+	if input.Stock != nil && *input.Stock > 0 {
+		if err := s.setVariantStock(ctx, productID, uint64(variant.ID), userID, *input.Stock); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetVariant(ctx, productID, uint64(variant.ID), userID)
+}
+
+func (s *ProductService) UpdateVariant(ctx context.Context, productID, id, userID uint64, input VariantInput) (*entity.Variant, error) {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateVariant(&input, product); err != nil {
+		return nil, err
+	}
+
+	variant := &entity.Variant{
+		ID:      uint(id),
+		SKU:     input.SKU,
+		Options: input.Options,
+	}
+	if input.PriceOverride != nil {
+		variant.PriceOverrideMinor = &input.PriceOverride.Amount
+	}
+
+	if err := s.variantRepo.Update(ctx, productID, variant, overrideCurrency(input)); err != nil {
+		return nil, err
+	}
+	if err := s.resubmit(ctx, productID); err != nil {
+		return nil, err
+	}
+	if input.Stock != nil {
+		if err := s.setVariantStock(ctx, productID, id, userID, *input.Stock); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetVariant(ctx, productID, id, userID)
+}
+
+func (s *ProductService) DeleteVariant(ctx context.Context, productID, id, userID uint64) error {
+	if err := s.authorize(ctx, productID, userID, orgRoleMember); err != nil {
+		return err
+	}
+
+	return s.variantRepo.Delete(ctx, productID, id, userID)
+}
+
+// validateVariant checks the input and normalizes its SKU and options. The
+// repository checks the currency of the price override again under a lock
+// on the product.
+func validateVariant(input *VariantInput, product *entity.Product) error {
+	input.SKU = strings.TrimSpace(input.SKU)
+	if !skuPattern.MatchString(input.SKU) {
+		return fmt.Errorf("%w: sku must be 1-64 letters, digits, dots, dashes or underscores", ErrInvalidVariant)
+	}
+
+	if len(input.Options) > maxVariantOptions {
+		return fmt.Errorf("%w: at most %d options are allowed", ErrInvalidVariant, maxVariantOptions)
+	}
+	options := make(map[string]string, len(input.Options))
+	for name, value := range input.Options {
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if name == "" || value == "" || len(name) > maxOptionLength || len(value) > maxOptionLength {
+			return fmt.Errorf("%w: option names and values must be 1-%d characters", ErrInvalidVariant, maxOptionLength)
+		}
+		options[name] = value
+	}
+	input.Options = options
+
+	if input.Stock != nil && *input.Stock < 0 {
+		return fmt.Errorf("%w: stock cannot be negative", ErrInvalidVariant)
+	}
+
+	if price := input.PriceOverride; price != nil {
+		if price.Currency != product.Currency {
+			return fmt.Errorf("%w: price override must be in %s", ErrInvalidVariant, product.Currency)
+		}
+		if err := validatePrice(*price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func overrideCurrency(input VariantInput) string {
+	if input.PriceOverride == nil {
+		return ""
+	}
+	return input.PriceOverride.Currency
+}
+
+// setVariantStock sets the stock on hand of a variant, recording the change
+// in the inventory ledger.
+func (s *ProductService) setVariantStock(ctx context.Context, productID, variantID, userID uint64, stock int) error {
+	_, err := s.inventoryRepo.Adjust(ctx, repository.InventoryChange{
+		ProductID: productID,
+		VariantID: variantID,
+		Set:       &stock,
+		ActorID:   userID,
+		Note:      "variant stock update",
+	})
+	return err
+}
+
+// setVariantPrice fills in the variant's effective price. A running promotion
+// replaces the product price and discounts price overrides by the same
+// proportion, rounded half away from zero.
+func setVariantPrice(variant *entity.Variant, product *entity.Product) {
+	price := product.EffectiveMoney()
+	if variant.PriceOverrideMinor != nil {
+		price.Amount = *variant.PriceOverrideMinor
+		if sale := product.Promotion; sale != nil {
+			price.Amount = discount(price.Amount, sale.PriceMinor, product.PriceMinor)
+		}
+	}
+	variant.OnSale = product.Promotion != nil
+	variant.PriceMinor = price.Amount
+	variant.Currency = price.Currency
+	variant.PriceFormatted = price.String()
+}
+
+// discount scales amount by sale/price without overflowing. Prices are
+// positive, so rounding half up rounds half away from zero.
+func discount(amount, sale, price int64) int64 {
+	n := new(big.Int).Mul(big.NewInt(amount), big.NewInt(sale))
+	n.Add(n.Lsh(n, 1), big.NewInt(price))
+	d := new(big.Int).Lsh(big.NewInt(price), 1)
+	return n.Quo(n, d).Int64()
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gauss2302/testcommm/product/internal/domain/entity"
+	"github.com/gauss2302/testcommm/product/internal/domain/money"
+)
+
+func TestValidateVariant(t *testing.T) {
+	product := &entity.Product{PriceMinor: 1000, Currency: "USD"}
+	stock := func(n int) *int { return &n }
+	price := func(amount int64, currency string) *money.Money {
+		return &money.Money{Amount: amount, Currency: currency}
+	}
+
+	tooMany := map[string]string{}
+	for i := 0; i <= maxVariantOptions; i++ {
+		tooMany[fmt.Sprintf("o%d", i)] = "x"
+	}
+
+	tests := []struct {
+		name    string
+		input   VariantInput
+		want    VariantInput
+		wantErr error
+	}{
+		{
+			name:  "normalized",
+			input: VariantInput{SKU: " TS-red_M.1 ", Options: map[string]string{" Size ": " M ", "COLOR": "red"}},
+			want:  VariantInput{SKU: "TS-red_M.1", Options: map[string]string{"size": "M", "color": "red"}},
+		},
+		{
+			name:  "override and stock",
+			input: VariantInput{SKU: "A", PriceOverride: price(1200, "USD"), Stock: stock(0)},
+			want:  VariantInput{SKU: "A", Options: map[string]string{}, PriceOverride: price(1200, "USD"), Stock: stock(0)},
+		},
+		{name: "empty sku", input: VariantInput{SKU: "  "}, wantErr: ErrInvalidVariant},
+		{name: "sku with space", input: VariantInput{SKU: "TS RED"}, wantErr: ErrInvalidVariant},
+		{name: "sku too long", input: VariantInput{SKU: strings.Repeat("A", 65)}, wantErr: ErrInvalidVariant},
+		{name: "too many options", input: VariantInput{SKU: "A", Options: tooMany}, wantErr: ErrInvalidVariant},
+		{name: "empty option value", input: VariantInput{SKU: "A", Options: map[string]string{"size": " "}}, wantErr: ErrInvalidVariant},
+		{name: "option too long", input: VariantInput{SKU: "A", Options: map[string]string{"size": strings.Repeat("x", maxOptionLength+1)}}, wantErr: ErrInvalidVariant},
+		{name: "negative stock", input: VariantInput{SKU: "A", Stock: stock(-1)}, wantErr: ErrInvalidVariant},
+		{name: "override in another currency", input: VariantInput{SKU: "A", PriceOverride: price(1200, "EUR")}, wantErr: ErrInvalidVariant},
+		{name: "zero override", input: VariantInput{SKU: "A", PriceOverride: price(0, "USD")}, wantErr: ErrInvalidPrice},
+	}
+
+	for _, tt := range tests {
+		input := tt.input
+		err := validateVariant(&input, product)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: validateVariant error = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(input, tt.want) {
+			t.Errorf("%s: validateVariant = %+v, want %+v", tt.name, input, tt.want)
+		}
+	}
+}
+
+func TestSetVariantPrice(t *testing.T) {
+	override := func(amount int64) *int64 { return &amount }
+	product := func(promotion *entity.Promotion) *entity.Product {
+		return &entity.Product{PriceMinor: 1000, Currency: "USD", Promotion: promotion}
+	}
+	sale := &entity.Promotion{PriceMinor: 750, Currency: "USD"}
+
+	tests := []struct {
+		name     string
+		override *int64
+		product  *entity.Product
+		want     int64
+		onSale   bool
+	}{
+		{"product price", nil, product(nil), 1000, false},
+		{"override", override(1200), product(nil), 1200, false},
+		{"sale price", nil, product(sale), 750, true},
+		{"override discounted in proportion", override(1200), product(sale), 900, true},
+		{"half rounds away from zero", override(2), product(sale), 2, true},
+		{"below half rounds down", override(3), product(sale), 2, true},
+		{"large override does not overflow", override(1 << 60), product(sale), 3 << 58, true},
+	}
+
+	for _, tt := range tests {
+		variant := &entity.Variant{PriceOverrideMinor: tt.override}
+		setVariantPrice(variant, tt.product)
+		if variant.PriceMinor != tt.want || variant.Currency != "USD" || variant.OnSale != tt.onSale {
+			t.Errorf("%s: price = %d %s, on sale %v, want %d USD, on sale %v",
+				tt.name, variant.PriceMinor, variant.Currency, variant.OnSale, tt.want, tt.onSale)
+		}
+	}
+}
@@ -2,8 +2,11 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"github.com/gauss2302/testcommm/user/config"
 	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"github.com/gauss2302/testcommm/user/internal/notifier"
 	"github.com/gauss2302/testcommm/user/internal/repository"
 	"github.com/gauss2302/testcommm/user/internal/service"
 	database "github.com/gauss2302/testcommm/user/pkg/databse"
@@ -14,6 +17,13 @@ import (
 func main() {
 	// Load Config
 	cfg := config.Load()
+	if len(cfg.CursorSecret) == 0 {
+		log.Printf("CURSOR_SECRET is not set, using a random key")
+		cfg.CursorSecret = make([]byte, 32)
+		if _, err := rand.Read(cfg.CursorSecret); err != nil {
+			log.Fatalf("Failed to generate cursor secret: %v", err)
+		}
+	}
 
 	// Set up the database
 	db, err := database.NewPostgresDB(cfg.Database)
@@ -22,13 +32,47 @@ func main() {
 	}
 
 	// Auto migrate
-	if err := db.AutoMigrate(&entity.User{}); err != nil {
+	if err := db.AutoMigrate(
+		&entity.User{},
+		&entity.EmailChange{},
+		&entity.StatusChange{},
+		&entity.Organization{},
+		&entity.Membership{},
+		&entity.Invitation{},
+		&entity.UserEvent{},
+		&entity.Preference{},
+	); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+	// Emails used to be unique across deleted accounts too
+	if db.Migrator().HasIndex(&entity.User{}, "idx_users_email") {
+		if err := db.Migrator().DropIndex(&entity.User{}, "idx_users_email"); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
 
 	// Initialize repositories and services
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
+	emailChangeRepo := repository.NewEmailChangeRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	eventRepo := repository.NewEventRepository(db)
+	prefRepo := repository.NewPreferenceRepository(db)
+
+	if err := userRepo.PromoteAdmins(cfg.AdminEmails); err != nil {
+		log.Fatalf("Failed to grant admin roles: %v", err)
+	}
+
+	// Outgoing mail falls back to the log when no SMTP server is configured
+	var mailer notifier.Notifier = notifier.NewLogNotifier()
+	if cfg.Mail.SMTPAddr != "" {
+		mailer = notifier.NewSMTPNotifier(cfg.Mail.SMTPAddr, cfg.Mail.From, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword)
+	}
+
+	userService := service.NewUserService(userRepo, emailChangeRepo, orgRepo, invitationRepo, eventRepo, prefRepo, mailer, cfg)
+
+	// Purge accounts whose deletion grace period has expired
+	go userService.RunPurger(context.Background())
 
 	// Initialize and start gRPC server
 	srv := server.NewServer(userService)
@@ -0,0 +1,133 @@
+// user-service/cmd/userctl/main.go
+//
+// userctl is an admin tool for bulk account operations:
+//
+//	userctl import [-format csv|jsonl] [-dry-run] FILE
+//	userctl export [-format csv|jsonl] [-include-hashes] [FILE]
+//
+// It connects to the database configured by DATABASE_URL.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gauss2302/testcommm/user/config"
+	"github.com/gauss2302/testcommm/user/internal/bulk"
+	"github.com/gauss2302/testcommm/user/internal/repository"
+	database "github.com/gauss2302/testcommm/user/pkg/databse"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "import":
+		runImport(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: userctl import [-format csv|jsonl] [-dry-run] FILE")
+	fmt.Fprintln(os.Stderr, "       userctl export [-format csv|jsonl] [-include-hashes] [FILE]")
+	os.Exit(2)
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "input format, csv or jsonl (default: from file extension)")
+	dryRun := fs.Bool("dry-run", false, "validate and report changes without writing")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	path := fs.Arg(0)
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	importer := bulk.NewImporter(userRepository())
+	importer.DryRun = *dryRun
+
+	report, err := importer.Import(f, formatFor(*format, path))
+	for _, rowErr := range report.Errors {
+		fmt.Fprintln(os.Stderr, rowErr.Error())
+	}
+
+	prefix := ""
+	if *dryRun {
+		prefix = "dry run: "
+	}
+	fmt.Printf("%screated %d, updated %d, unchanged %d, failed %d\n",
+		prefix, report.Created, report.Updated, report.Unchanged, len(report.Errors))
+
+	if err != nil {
+		log.Fatalf("Import aborted: %v", err)
+	}
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "output format, csv or jsonl (default: from file extension, else csv)")
+	includeHashes := fs.Bool("include-hashes", false, "include bcrypt password hashes")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		usage()
+	}
+
+	var out io.Writer = os.Stdout
+	path := fs.Arg(0)
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", path, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	exported, err := bulk.Export(userRepository(), out, formatFor(*format, path), *includeHashes)
+	if err != nil {
+		log.Fatalf("Export failed after %d users: %v", exported, err)
+	}
+	log.Printf("Exported %d users", exported)
+}
+
+// formatFor returns the explicit format, or infers it from the file name.
+func formatFor(format, path string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return bulk.FormatJSONL
+	}
+	return bulk.FormatCSV
+}
+
+func userRepository() *repository.UserRepository {
+	cfg := config.Load()
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	return repository.NewUserRepository(db)
+}
@@ -2,12 +2,23 @@ package config
 
 import (
 	"os"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Database DataBaseConfig
-	GRPC     GRPCConfig
+	Database    DataBaseConfig
+	GRPC        GRPCConfig
+	Mail        MailConfig
+	EmailChange EmailChangeConfig
+	Deletion    DeletionConfig
+	Invitation  InvitationConfig
+	Events      EventsConfig
+	// AdminEmails lists accounts that are granted the admin role.
+	AdminEmails []string
+	// CursorSecret signs directory cursors. Replicas must share it,
+	// otherwise cursors only work against the replica that issued them.
+	CursorSecret []byte
 }
 
 type DataBaseConfig struct {
@@ -20,6 +31,37 @@ type GRPCConfig struct {
 	Port string
 }
 
+type MailConfig struct {
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+	// PublicURL is the externally reachable base URL of the auth API, used
+	// to build links in outgoing messages.
+	PublicURL string
+}
+
+type EmailChangeConfig struct {
+	ConfirmTTL time.Duration
+	RevertTTL  time.Duration
+}
+
+type DeletionConfig struct {
+	// GracePeriod is how long a deleted account is kept before it is purged.
+	GracePeriod   time.Duration
+	PurgeInterval time.Duration
+}
+
+type InvitationConfig struct {
+	TTL time.Duration
+}
+
+type EventsConfig struct {
+	// PollInterval is how often watchers check the outbox for new events.
+	PollInterval time.Duration
+	Retention    time.Duration
+}
+
 func Load() *Config {
 	return &Config{
 		Database: DataBaseConfig{
@@ -30,6 +72,30 @@ func Load() *Config {
 		GRPC: GRPCConfig{
 			Port: genEnvOrDefault("GRPC_PORT", "50051"),
 		},
+		Mail: MailConfig{
+			SMTPAddr:     os.Getenv("SMTP_ADDR"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			From:         genEnvOrDefault("MAIL_FROM", "no-reply@localhost"),
+			PublicURL:    genEnvOrDefault("PUBLIC_URL", "http://localhost:8000/auth"),
+		},
+		EmailChange: EmailChangeConfig{
+			ConfirmTTL: durationEnvOrDefault("EMAIL_CHANGE_TTL", 24*time.Hour),
+			RevertTTL:  durationEnvOrDefault("EMAIL_REVERT_TTL", 7*24*time.Hour),
+		},
+		Deletion: DeletionConfig{
+			GracePeriod:   durationEnvOrDefault("DELETION_GRACE_PERIOD", 30*24*time.Hour),
+			PurgeInterval: durationEnvOrDefault("PURGE_INTERVAL", time.Hour),
+		},
+		Invitation: InvitationConfig{
+			TTL: durationEnvOrDefault("INVITATION_TTL", 7*24*time.Hour),
+		},
+		Events: EventsConfig{
+			PollInterval: durationEnvOrDefault("EVENT_POLL_INTERVAL", time.Second),
+			Retention:    durationEnvOrDefault("EVENT_RETENTION", 7*24*time.Hour),
+		},
+		AdminEmails:  listEnv("ADMIN_EMAILS"),
+		CursorSecret: []byte(os.Getenv("CURSOR_SECRET")),
 	}
 }
 
@@ -39,3 +105,22 @@ func genEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func durationEnvOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func listEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
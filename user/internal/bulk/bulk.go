@@ -0,0 +1,343 @@
+// Package bulk imports and exports user accounts as CSV or JSON Lines.
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"github.com/gauss2302/testcommm/user/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// Row is a single account in an import or export file. Exactly one of
+// Password and PasswordHash must be set on import.
+type Row struct {
+	Email        string `json:"email"`
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"password_hash,omitempty"`
+	Role         string `json:"role,omitempty"`
+	Status       string `json:"status,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+}
+
+var csvHeader = []string{"email", "password", "password_hash", "role", "status", "created_at"}
+
+// RowError reports why a row of the input was rejected.
+type RowError struct {
+	Line  int
+	Email string
+	Err   error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d (%s): %v", e.Line, e.Email, e.Err)
+}
+
+type Report struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Errors    []RowError
+}
+
+type Importer struct {
+	userRepo *repository.UserRepository
+	// DryRun validates rows and reports what would change without writing.
+	DryRun bool
+}
+
+func NewImporter(userRepo *repository.UserRepository) *Importer {
+	return &Importer{userRepo: userRepo}
+}
+
+// Import reads rows in the given format and upserts them by email. Invalid
+// rows are collected in the report and do not stop the import.
+func (im *Importer) Import(r io.Reader, format string) (*Report, error) {
+	report := &Report{}
+
+	err := readRows(r, format, func(line int, row Row, err error) {
+		if err == nil {
+			var result repository.UpsertResult
+			result, err = im.importRow(row)
+			if err == nil {
+				report.count(result)
+				return
+			}
+		}
+		report.Errors = append(report.Errors, RowError{Line: line, Email: row.Email, Err: err})
+	})
+	return report, err
+}
+
+func (r *Report) count(result repository.UpsertResult) {
+	switch result {
+	case repository.UpsertCreated:
+		r.Created++
+	case repository.UpsertUpdated:
+		r.Updated++
+	default:
+		r.Unchanged++
+	}
+}
+
+func (im *Importer) importRow(row Row) (repository.UpsertResult, error) {
+	if err := validate(&row); err != nil {
+		return 0, err
+	}
+
+	user := &entity.User{
+		Email:  row.Email,
+		Role:   row.Role,
+		Status: row.Status,
+	}
+	// Only new accounts take the creation time of the row
+	if row.CreatedAt != "" {
+		user.CreatedAt, _ = time.Parse(time.RFC3339, row.CreatedAt)
+	}
+	password := func(existing *entity.User) (string, error) {
+		return passwordHash(row, existing)
+	}
+
+	if im.DryRun {
+		existing, err := im.userRepo.GetByEmail(row.Email)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.UpsertCreated, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if user.Password, err = password(existing); err != nil {
+			return 0, err
+		}
+		if existing.Password != user.Password || existing.Role != user.Role || existing.Status != user.Status {
+			return repository.UpsertUpdated, nil
+		}
+		return repository.UpsertUnchanged, nil
+	}
+
+	return im.userRepo.UpsertByEmail(user, password)
+}
+
+func validate(row *Row) error {
+	row.Email = strings.TrimSpace(row.Email)
+	if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email {
+		return errors.New("invalid email address")
+	}
+
+	switch {
+	case row.Password != "" && row.PasswordHash != "":
+		return errors.New("only one of password and password_hash may be set")
+	case row.Password == "" && row.PasswordHash == "":
+		return errors.New("password or password_hash is required")
+	case row.PasswordHash != "":
+		if _, err := bcrypt.Cost([]byte(row.PasswordHash)); err != nil {
+			return errors.New("password_hash is not a bcrypt hash")
+		}
+	}
+
+	if row.Role == "" {
+		row.Role = entity.RoleUser
+	}
+	if row.Role != entity.RoleUser && row.Role != entity.RoleAdmin {
+		return fmt.Errorf("unknown role %q", row.Role)
+	}
+
+	if row.Status == "" {
+		row.Status = entity.StatusActive
+	}
+	if !entity.ValidStatus(row.Status) {
+		return fmt.Errorf("unknown status %q", row.Status)
+	}
+
+	row.CreatedAt = strings.TrimSpace(row.CreatedAt)
+	if row.CreatedAt != "" {
+		createdAt, err := time.Parse(time.RFC3339, row.CreatedAt)
+		if err != nil {
+			return errors.New("created_at must be an RFC 3339 time")
+		}
+		if createdAt.After(time.Now()) {
+			return errors.New("created_at is in the future")
+		}
+	}
+
+	return nil
+}
+
+// passwordHash returns the hash to store for the row. A plaintext password
+// that already matches the existing hash keeps it, so repeated imports of the
+// same file leave accounts unchanged; a hash in the row is used as it is.
+func passwordHash(row Row, existing *entity.User) (string, error) {
+	if row.PasswordHash != "" {
+		return row.PasswordHash, nil
+	}
+	if existing != nil && bcrypt.CompareHashAndPassword([]byte(existing.Password), []byte(row.Password)) == nil {
+		return existing.Password, nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(row.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func readRows(r io.Reader, format string, fn func(line int, row Row, err error)) error {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, fn)
+	case FormatJSONL:
+		return readJSONL(r, fn)
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+func readCSV(r io.Reader, fn func(line int, row Row, err error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read header: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return errors.New("header must contain an email column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			fn(parseErr.StartLine, Row{}, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+
+		fn(line, Row{
+			Email:        field(record, "email"),
+			Password:     field(record, "password"),
+			PasswordHash: field(record, "password_hash"),
+			Role:         field(record, "role"),
+			Status:       field(record, "status"),
+			CreatedAt:    field(record, "created_at"),
+		}, nil)
+	}
+}
+
+func readJSONL(r io.Reader, fn func(line int, row Row, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var row Row
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			fn(line, row, fmt.Errorf("invalid JSON: %v", err))
+			continue
+		}
+		fn(line, row, nil)
+	}
+	return scanner.Err()
+}
+
+// Export writes every account in the given format, ordered by ID. Password
+// hashes are only included when includeHashes is set.
+func Export(userRepo *repository.UserRepository, w io.Writer, format string, includeHashes bool) (int, error) {
+	write, flush, err := writeRows(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	const pageSize = 1000
+	exported := 0
+	filter := repository.UserFilter{SortBy: "id", Limit: pageSize}
+	for {
+		users, err := userRepo.List(filter)
+		if err != nil {
+			return exported, err
+		}
+
+		for _, user := range users {
+			row := Row{
+				Email:     user.Email,
+				Role:      user.Role,
+				Status:    user.Status,
+				CreatedAt: user.CreatedAt.Format(time.RFC3339),
+			}
+			if includeHashes {
+				row.PasswordHash = user.Password
+			}
+			if err := write(row); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+
+		if len(users) < pageSize {
+			return exported, flush()
+		}
+		cursor := repository.CursorFor(users[len(users)-1], filter.SortBy)
+		filter.After = &cursor
+	}
+}
+
+// writeRows returns functions that write rows in the given format, in a form
+// readRows reads back, and flush them once done.
+func writeRows(w io.Writer, format string) (write func(Row) error, flush func() error, err error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, nil, err
+		}
+		write = func(row Row) error {
+			return cw.Write([]string{row.Email, row.Password, row.PasswordHash, row.Role, row.Status, row.CreatedAt})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		write = func(row Row) error { return enc.Encode(row) }
+		flush = func() error { return nil }
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q", format)
+	}
+	return write, flush, nil
+}
@@ -0,0 +1,223 @@
+package bulk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+)
+
+func TestRowsRoundTrip(t *testing.T) {
+	rows := []Row{
+		{Email: "alice@example.com", PasswordHash: "$2a$10$abcdefghijklmnopqrstuv", Role: "admin", Status: "active", CreatedAt: "2024-01-02T03:04:05Z"},
+		{Email: "bob@example.com", Role: "user", Status: "suspended", CreatedAt: "2023-12-31T23:59:59+02:00"},
+		{Email: "carol@example.com", Password: "secret, with \"quotes\"\nand a newline"},
+		{Email: "dave@example.com"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		var buf bytes.Buffer
+		write, flush, err := writeRows(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: writeRows: %v", format, err)
+		}
+		for _, row := range rows {
+			if err := write(row); err != nil {
+				t.Fatalf("%s: write(%+v): %v", format, row, err)
+			}
+		}
+		if err := flush(); err != nil {
+			t.Fatalf("%s: flush: %v", format, err)
+		}
+
+		var got []Row
+		err = readRows(&buf, format, func(line int, row Row, err error) {
+			if err != nil {
+				t.Errorf("%s: line %d: %v", format, line, err)
+			}
+			got = append(got, row)
+		})
+		if err != nil {
+			t.Fatalf("%s: readRows: %v", format, err)
+		}
+		if len(got) != len(rows) {
+			t.Fatalf("%s: read %d rows, want %d", format, len(got), len(rows))
+		}
+		for i := range rows {
+			if got[i] != rows[i] {
+				t.Errorf("%s: row %d = %+v, want %+v", format, i, got[i], rows[i])
+			}
+		}
+	}
+}
+
+func TestReadRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []Row
+		errors  []int
+		failure bool
+	}{
+		{
+			name:   "csv columns in any order",
+			format: FormatCSV,
+			input:  "Status, EMAIL,password\nactive,a@example.com,pw\n",
+			want:   []Row{{Email: "a@example.com", Password: "pw", Status: "active"}},
+		},
+		{
+			name:   "csv short record",
+			format: FormatCSV,
+			input:  "email,password,created_at\na@example.com\n",
+			want:   []Row{{Email: "a@example.com"}},
+		},
+		{
+			name:   "csv bad quoting skips the row",
+			format: FormatCSV,
+			input:  "email\n\"a@example.com\n",
+			want:   []Row{{}},
+			errors: []int{2},
+		},
+		{
+			name:    "csv without email column",
+			format:  FormatCSV,
+			input:   "password\npw\n",
+			failure: true,
+		},
+		{
+			name:   "jsonl blank lines and bad json",
+			format: FormatJSONL,
+			input:  "{\"email\":\"a@example.com\",\"created_at\":\"2024-01-01T00:00:00Z\"}\n\n{oops}\n",
+			want: []Row{
+				{Email: "a@example.com", CreatedAt: "2024-01-01T00:00:00Z"},
+				{},
+			},
+			errors: []int{3},
+		},
+		{
+			name:    "unknown format",
+			format:  "xml",
+			failure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		var got []Row
+		var errorLines []int
+		err := readRows(strings.NewReader(tt.input), tt.format, func(line int, row Row, err error) {
+			got = append(got, row)
+			if err != nil {
+				errorLines = append(errorLines, line)
+			}
+		})
+		if tt.failure != (err != nil) {
+			t.Errorf("%s: readRows error = %v, want failure %v", tt.name, err, tt.failure)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: read %d rows, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: row %d = %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+		if len(errorLines) != len(tt.errors) {
+			t.Errorf("%s: errors on lines %v, want %v", tt.name, errorLines, tt.errors)
+			continue
+		}
+		for i := range tt.errors {
+			if errorLines[i] != tt.errors[i] {
+				t.Errorf("%s: errors on lines %v, want %v", tt.name, errorLines, tt.errors)
+				break
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	const hash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+
+	tests := []struct {
+		name    string
+		row     Row
+		want    Row
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			row:  Row{Email: " a@example.com ", Password: "pw"},
+			want: Row{Email: "a@example.com", Password: "pw", Role: entity.RoleUser, Status: entity.StatusActive},
+		},
+		{
+			name: "created_at trimmed",
+			row:  Row{Email: "a@example.com", PasswordHash: hash, CreatedAt: " 2024-01-02T03:04:05Z "},
+			want: Row{Email: "a@example.com", PasswordHash: hash, Role: entity.RoleUser, Status: entity.StatusActive, CreatedAt: "2024-01-02T03:04:05Z"},
+		},
+		{name: "display name", row: Row{Email: "Alice <a@example.com>", Password: "pw"}, wantErr: true},
+		{name: "no password", row: Row{Email: "a@example.com"}, wantErr: true},
+		{name: "both passwords", row: Row{Email: "a@example.com", Password: "pw", PasswordHash: hash}, wantErr: true},
+		{name: "hash not bcrypt", row: Row{Email: "a@example.com", PasswordHash: "md5:abc"}, wantErr: true},
+		{name: "unknown role", row: Row{Email: "a@example.com", Password: "pw", Role: "root"}, wantErr: true},
+		{name: "unknown status", row: Row{Email: "a@example.com", Password: "pw", Status: "gone"}, wantErr: true},
+		{name: "created_at not rfc 3339", row: Row{Email: "a@example.com", Password: "pw", CreatedAt: "2024-01-02"}, wantErr: true},
+		{name: "created_at in the future", row: Row{Email: "a@example.com", Password: "pw", CreatedAt: "2999-01-01T00:00:00Z"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		row := tt.row
+		err := validate(&row)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("%s: validate(%+v) error = %v, want error %v", tt.name, tt.row, err, tt.wantErr)
+			continue
+		}
+		if err == nil && row != tt.want {
+			t.Errorf("%s: validate(%+v) = %+v, want %+v", tt.name, tt.row, row, tt.want)
+		}
+	}
+}
+
+func TestPasswordHash(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := &entity.User{Password: string(hash)}
+	const otherHash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+
+	tests := []struct {
+		name     string
+		row      Row
+		existing *entity.User
+		keep     bool
+		matches  string
+	}{
+		{name: "same password keeps the hash", row: Row{Password: "secret"}, existing: existing, keep: true},
+		{name: "new password is hashed", row: Row{Password: "changed"}, existing: existing, matches: "changed"},
+		{name: "new account is hashed", row: Row{Password: "secret"}, matches: "secret"},
+		{name: "same hash is kept", row: Row{PasswordHash: string(hash)}, existing: existing, keep: true},
+		{name: "other hash is used as is", row: Row{PasswordHash: otherHash}, existing: existing},
+	}
+
+	for _, tt := range tests {
+		got, err := passwordHash(tt.row, tt.existing)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.keep != (got == string(hash)) {
+			t.Errorf("%s: kept the existing hash = %v, want %v", tt.name, !tt.keep, tt.keep)
+		}
+		if tt.row.PasswordHash != "" && got != tt.row.PasswordHash {
+			t.Errorf("%s: hash = %q, want %q", tt.name, got, tt.row.PasswordHash)
+		}
+		if tt.matches != "" && bcrypt.CompareHashAndPassword([]byte(got), []byte(tt.matches)) != nil {
+			t.Errorf("%s: hash does not match %q", tt.name, tt.matches)
+		}
+	}
+}
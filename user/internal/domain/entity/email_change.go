@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// EmailChange tracks a pending or completed change of a user's email address.
+// Only hashes of the confirmation and revert tokens are stored.
+type EmailChange struct {
+	ID              uint   `gorm:"primarykey"`
+	UserID          uint   `gorm:"index;not null"`
+	OldEmail        string `gorm:"not null"`
+	NewEmail        string `gorm:"not null"`
+	TokenHash       string `gorm:"uniqueIndex;not null"`
+	RevertTokenHash string `gorm:"index"`
+	ExpiresAt       time.Time
+	ConfirmedAt     *time.Time
+	RevertedAt      *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Pending reports whether the confirmation link still works at now. Creating
+// a newer change expires the earlier ones.
+func (c *EmailChange) Pending(now time.Time) bool {
+	return c.ConfirmedAt == nil && now.Before(c.ExpiresAt)
+}
+
+// Revertable reports whether the revert link of a confirmed change still
+// works at now.
+func (c *EmailChange) Revertable(now time.Time) bool {
+	return c.ConfirmedAt != nil && c.RevertedAt == nil && now.Before(c.ExpiresAt)
+}
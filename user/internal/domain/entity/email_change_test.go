@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEmailChangeLinks(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		change     EmailChange
+		pending    bool
+		revertable bool
+	}{
+		{"unconfirmed", EmailChange{ExpiresAt: now.Add(time.Hour)}, true, false},
+		{"expired", EmailChange{ExpiresAt: now.Add(-time.Second)}, false, false},
+		{"superseded by a newer request", EmailChange{ExpiresAt: now}, false, false},
+		{"confirmed", EmailChange{ExpiresAt: now.Add(time.Hour), ConfirmedAt: &earlier}, false, true},
+		{"confirmed, revert link expired", EmailChange{ExpiresAt: now.Add(-time.Second), ConfirmedAt: &earlier}, false, false},
+		{"reverted", EmailChange{ExpiresAt: now.Add(time.Hour), ConfirmedAt: &earlier, RevertedAt: &earlier}, false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.change.Pending(now); got != tt.pending {
+			t.Errorf("%s: Pending = %v, want %v", tt.name, got, tt.pending)
+		}
+		if got := tt.change.Revertable(now); got != tt.revertable {
+			t.Errorf("%s: Revertable = %v, want %v", tt.name, got, tt.revertable)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Invitation offers membership in an organization to an email address that
+// may not have an account yet. Only a hash of the token is stored.
+type Invitation struct {
+	ID             uint   `gorm:"primarykey"`
+	OrganizationID uint   `gorm:"index;not null"`
+	Email          string `gorm:"not null"`
+	Role           string `gorm:"not null"`
+	TokenHash      string `gorm:"uniqueIndex;not null"`
+	InvitedBy      uint   `gorm:"not null"`
+	ExpiresAt      time.Time
+	AcceptedAt     *time.Time
+	AcceptedBy     *uint
+	RevokedAt      *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	Organization Organization `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Pending reports whether the invitation can still be accepted at now.
+func (i *Invitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// SentTo reports whether the invitation was addressed to email. Addresses
+// compare case-insensitively.
+func (i *Invitation) SentTo(email string) bool {
+	return strings.EqualFold(strings.TrimSpace(email), i.Email)
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInvitationPending(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+	accepter := uint(7)
+
+	tests := []struct {
+		name       string
+		invitation Invitation
+		want       bool
+	}{
+		{"open", Invitation{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", Invitation{ExpiresAt: now.Add(-time.Second)}, false},
+		{"expires now", Invitation{ExpiresAt: now}, false},
+		{"accepted", Invitation{ExpiresAt: now.Add(time.Hour), AcceptedAt: &earlier, AcceptedBy: &accepter}, false},
+		{"revoked", Invitation{ExpiresAt: now.Add(time.Hour), RevokedAt: &earlier}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.invitation.Pending(now); got != tt.want {
+			t.Errorf("%s: Pending = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInvitationSentTo(t *testing.T) {
+	invitation := &Invitation{Email: "Alice@Example.com"}
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"Alice@Example.com", true},
+		{"alice@example.com", true},
+		{" alice@example.com ", true},
+		{"bob@example.com", false},
+		{"alice@example.org", false},
+		{"alice+shop@example.com", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := invitation.SentTo(tt.email); got != tt.want {
+			t.Errorf("SentTo(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// OrgRoleRank orders organization roles by privilege. Unknown roles rank 0.
+func OrgRoleRank(role string) int {
+	switch role {
+	case OrgRoleOwner:
+		return 3
+	case OrgRoleAdmin:
+		return 2
+	case OrgRoleMember:
+		return 1
+	}
+	return 0
+}
+
+type Organization struct {
+	ID        uint   `gorm:"primarykey"`
+	Name      string `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Membership struct {
+	ID             uint   `gorm:"primarykey"`
+	OrganizationID uint   `gorm:"uniqueIndex:idx_membership_org_user;not null"`
+	UserID         uint   `gorm:"uniqueIndex:idx_membership_org_user;index;not null"`
+	Role           string `gorm:"not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	User         User         `gorm:"constraint:OnDelete:CASCADE"`
+	Organization Organization `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// HasRole reports whether the member's role is at least role.
+func (m *Membership) HasRole(role string) bool {
+	return OrgRoleRank(m.Role) >= OrgRoleRank(role)
+}
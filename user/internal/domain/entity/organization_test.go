@@ -0,0 +1,31 @@
+package entity
+
+import "testing"
+
+func TestMembershipHasRole(t *testing.T) {
+	tests := []struct {
+		have string
+		want string
+		ok   bool
+	}{
+		{OrgRoleOwner, OrgRoleOwner, true},
+		{OrgRoleOwner, OrgRoleAdmin, true},
+		{OrgRoleOwner, OrgRoleMember, true},
+		{OrgRoleAdmin, OrgRoleOwner, false},
+		{OrgRoleAdmin, OrgRoleAdmin, true},
+		{OrgRoleAdmin, OrgRoleMember, true},
+		{OrgRoleMember, OrgRoleOwner, false},
+		{OrgRoleMember, OrgRoleAdmin, false},
+		{OrgRoleMember, OrgRoleMember, true},
+		{"", OrgRoleMember, false},
+		{"Owner", OrgRoleMember, false},
+		{"billing", OrgRoleMember, false},
+	}
+
+	for _, tt := range tests {
+		m := &Membership{Role: tt.have}
+		if got := m.HasRole(tt.want); got != tt.ok {
+			t.Errorf("Membership{Role: %q}.HasRole(%q) = %v, want %v", tt.have, tt.want, got, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// Preference is a single user setting. Values are stored in their canonical
+// string form; the schema in the service layer defines keys and types.
+type Preference struct {
+	UserID    uint   `gorm:"primarykey"`
+	Key       string `gorm:"primarykey"`
+	Value     string `gorm:"not null"`
+	UpdatedAt time.Time
+
+	User User `gorm:"constraint:OnDelete:CASCADE"`
+}
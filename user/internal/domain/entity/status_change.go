@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// StatusChange is an audit record of an account status transition.
+type StatusChange struct {
+	ID         uint   `gorm:"primarykey"`
+	UserID     uint   `gorm:"index;not null"`
+	FromStatus string `gorm:"not null"`
+	ToStatus   string `gorm:"not null"`
+	Reason     string
+	ActorID    uint
+	CreatedAt  time.Time
+}
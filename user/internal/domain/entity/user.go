@@ -1,11 +1,47 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusBanned    = "banned"
+	StatusPending   = "pending"
+)
+
+// ValidStatus reports whether status is one of the known account statuses.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusSuspended, StatusBanned, StatusPending:
+		return true
+	}
+	return false
+}
 
 type User struct {
-	ID        uint   `gorm:"primarykey"`
-	Email     string `gorm:"uniqueIndex;not null"`
-	Password  string `gorm:"not null"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID uint `gorm:"primarykey"`
+	// Email is unique among accounts that are not deleted, so that an
+	// address can sign up again while its old account awaits purge.
+	Email    string `gorm:"uniqueIndex:idx_users_live_email,where:deleted_at IS NULL;not null"`
+	Password string `gorm:"not null"`
+	Role     string `gorm:"not null;default:user;index"`
+	Status   string `gorm:"not null;default:active;index"`
+	// StatusReason explains the last status change, e.g. why an account was
+	// suspended.
+	StatusReason    string
+	StatusChangedAt *time.Time
+	CreatedAt       time.Time `gorm:"index"`
+	UpdatedAt       time.Time
+	// DeletedAt marks an account scheduled for erasure. The row is purged
+	// once the deletion grace period has passed.
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
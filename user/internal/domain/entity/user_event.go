@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+const (
+	EventUserCreated       = "user.created"
+	EventUserUpdated       = "user.updated"
+	EventUserStatusChanged = "user.status_changed"
+	EventUserDeleted       = "user.deleted"
+	EventUserPurged        = "user.purged"
+)
+
+// UserEvent is an outbox record written in the same transaction as the change
+// it describes. IDs increase in commit order and serve as stream cursors.
+type UserEvent struct {
+	ID        uint64    `gorm:"primarykey"`
+	UserID    uint      `gorm:"index;not null"`
+	Type      string    `gorm:"not null"`
+	Payload   string    `gorm:"type:jsonb;not null"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// UserSnapshot is the state of a user carried in an event payload.
+type UserSnapshot struct {
+	ID           uint      `json:"id"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	Status       string    `json:"status"`
+	StatusReason string    `json:"status_reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Implementations must be safe for
+// concurrent use.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogNotifier writes messages to the service log instead of delivering them.
+// It is used when no SMTP server is configured.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg Message) error {
+	log.Printf("Notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPNotifier(addr, from, username, password string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{addr: addr, from: from, auth: auth}
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		n.from, msg.To, msg.Subject, msg.Body)
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{msg.To}, []byte(body)); err != nil {
+		return fmt.Errorf("failed to send mail to %s: %v", msg.To, err)
+	}
+	return nil
+}
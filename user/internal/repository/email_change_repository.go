@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEmailTaken     = errors.New("email already in use")
+	ErrEmailChanged   = errors.New("email changed since the request was made")
+	ErrChangeNotFound = errors.New("email change not found or expired")
+)
+
+type EmailChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailChangeRepository(db *gorm.DB) *EmailChangeRepository {
+	return &EmailChangeRepository{db: db}
+}
+
+// Create stores a new pending change and expires the user's earlier pending
+// ones, so that only the latest confirmation link works.
+func (r *EmailChangeRepository) Create(change *entity.EmailChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&entity.EmailChange{}).
+			Where("user_id = ? AND confirmed_at IS NULL AND expires_at > ?", change.UserID, now).
+			Update("expires_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// GetPendingByTokenHash returns an unconfirmed, unexpired change.
+func (r *EmailChangeRepository) GetPendingByTokenHash(hash string) (*entity.EmailChange, error) {
+	change, err := r.getBy("token_hash", hash)
+	if err != nil {
+		return nil, err
+	}
+	if !change.Pending(time.Now()) {
+		return nil, ErrChangeNotFound
+	}
+	return change, nil
+}
+
+// GetRevertableByTokenHash returns a confirmed change whose revert link is
+// still valid.
+func (r *EmailChangeRepository) GetRevertableByTokenHash(hash string) (*entity.EmailChange, error) {
+	change, err := r.getBy("revert_token_hash", hash)
+	if err != nil {
+		return nil, err
+	}
+	if !change.Revertable(time.Now()) {
+		return nil, ErrChangeNotFound
+	}
+	return change, nil
+}
+
+func (r *EmailChangeRepository) getBy(column, hash string) (*entity.EmailChange, error) {
+	var change entity.EmailChange
+	err := r.db.Where(column+" = ?", hash).First(&change).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrChangeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// Confirm swaps the user's email from OldEmail to NewEmail and marks the
+// change as confirmed in a single transaction. The revert token replaces the
+// confirmation token and is valid until revertExpiresAt.
+func (r *EmailChangeRepository) Confirm(change *entity.EmailChange, revertTokenHash string, revertExpiresAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := swapEmail(tx, change.UserID, change.OldEmail, change.NewEmail); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		change.ConfirmedAt = &now
+		change.RevertTokenHash = revertTokenHash
+		change.ExpiresAt = revertExpiresAt
+		return tx.Save(change).Error
+	})
+}
+
+// Revert restores OldEmail on the user and marks the change as reverted.
+func (r *EmailChangeRepository) Revert(change *entity.EmailChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := swapEmail(tx, change.UserID, change.NewEmail, change.OldEmail); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		change.RevertedAt = &now
+		return tx.Save(change).Error
+	})
+}
+
+// swapEmail updates the email of a locked user row, failing if it no longer
+// holds from or if to is owned by another account.
+func swapEmail(tx *gorm.DB, userID uint, from, to string) error {
+	var user entity.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.Email != from {
+		return ErrEmailChanged
+	}
+
+	err := tx.Model(&user).Update("email", to).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	user.Email = to
+	return recordEvent(tx, entity.EventUserUpdated, &user)
+}
+
+func (r *EmailChangeRepository) ListByUserID(userID uint) ([]*entity.EmailChange, error) {
+	var changes []*entity.EmailChange
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+// eventLockID is the advisory lock serializing outbox writers, so that event
+// IDs are committed in increasing order and readers never skip an event that
+// commits late.
+const eventLockID = 0x75736572
+
+type EventRepository struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// recordEvent appends an event to the outbox within tx. Purge events only
+// carry the user's ID, as nothing else about the user may be kept.
+func recordEvent(tx *gorm.DB, eventType string, user *entity.User) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", eventLockID).Error; err != nil {
+		return err
+	}
+
+	var snapshot interface{} = entity.UserSnapshot{
+		ID:           user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		Status:       user.Status,
+		StatusReason: user.StatusReason,
+		CreatedAt:    user.CreatedAt,
+	}
+	if eventType == entity.EventUserPurged {
+		snapshot = struct {
+			ID uint `json:"id"`
+		}{user.ID}
+	}
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&entity.UserEvent{
+		UserID:  user.ID,
+		Type:    eventType,
+		Payload: string(payload),
+	}).Error
+}
+
+// ListAfter returns up to limit events with an ID greater than afterID,
+// optionally restricted to the given types.
+func (r *EventRepository) ListAfter(afterID uint64, types []string, limit int) ([]*entity.UserEvent, error) {
+	query := r.db.Where("id > ?", afterID)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+
+	var events []*entity.UserEvent
+	if err := query.Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// OldestID returns the ID of the oldest event not pruned yet, or 0 if there
+// are none.
+func (r *EventRepository) OldestID() (uint64, error) {
+	var id uint64
+	err := r.db.Model(&entity.UserEvent{}).Select("COALESCE(MIN(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+func (r *EventRepository) LatestID() (uint64, error) {
+	var id uint64
+	err := r.db.Model(&entity.UserEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
+// DeleteBefore prunes events older than the retention period.
+func (r *EventRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&entity.UserEvent{})
+	return result.RowsAffected, result.Error
+}
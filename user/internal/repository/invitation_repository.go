@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvitationNotFound = errors.New("invitation not found or expired")
+
+type InvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+func (r *InvitationRepository) Create(invitation *entity.Invitation) error {
+	return r.db.Omit(clause.Associations).Create(invitation).Error
+}
+
+// pending restricts a query to invitations that can still be accepted.
+func pending(db *gorm.DB) *gorm.DB {
+	return db.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", time.Now())
+}
+
+func (r *InvitationRepository) ListPending(orgID uint64) ([]*entity.Invitation, error) {
+	var invitations []*entity.Invitation
+	if err := r.db.Scopes(pending).Preload("Organization").
+		Where("organization_id = ?", orgID).
+		Order("created_at").
+		Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *InvitationRepository) GetPendingByTokenHash(hash string) (*entity.Invitation, error) {
+	var invitation entity.Invitation
+	err := r.db.Preload("Organization").
+		Where("token_hash = ?", hash).
+		First(&invitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !invitation.Pending(time.Now()) {
+		return nil, ErrInvitationNotFound
+	}
+	return &invitation, nil
+}
+
+// Revoke cancels a pending invitation of the organization.
+func (r *InvitationRepository) Revoke(orgID, id uint64) error {
+	result := r.db.Model(&entity.Invitation{}).Scopes(pending).
+		Where("id = ? AND organization_id = ?", id, orgID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Accept adds the user to the organization and marks the invitation as used
+// in one transaction, so an invitation can only be redeemed once.
+func (r *InvitationRepository) Accept(invitation *entity.Invitation, userID uint) (*entity.Membership, error) {
+	membership := &entity.Membership{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&entity.Invitation{}).Scopes(pending).
+			Where("id = ?", invitation.ID).
+			Updates(map[string]interface{}{"accepted_at": now, "accepted_by": userID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationNotFound
+		}
+
+		err := tx.Omit(clause.Associations).Create(membership).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrAlreadyMember
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
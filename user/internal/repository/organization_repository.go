@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAlreadyMember = errors.New("user is already a member")
+	ErrLastOwner     = errors.New("organization must keep at least one owner")
+)
+
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// Create stores the organization and makes ownerID its first owner.
+func (r *OrganizationRepository) Create(org *entity.Organization, ownerID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(&entity.Membership{
+			OrganizationID: org.ID,
+			UserID:         ownerID,
+			Role:           entity.OrgRoleOwner,
+		}).Error
+	})
+}
+
+func (r *OrganizationRepository) GetByID(id uint64) (*entity.Organization, error) {
+	var org entity.Organization
+	if err := r.db.First(&org, id).Error; err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *OrganizationRepository) AddMember(membership *entity.Membership) error {
+	err := r.db.Omit(clause.Associations).Create(membership).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrAlreadyMember
+	}
+	return err
+}
+
+// RemoveMember deletes the membership, refusing to remove the last owner.
+func (r *OrganizationRepository) RemoveMember(orgID, userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var memberships []*entity.Membership
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("organization_id = ?", orgID).
+			Find(&memberships).Error; err != nil {
+			return err
+		}
+
+		var target *entity.Membership
+		owners := 0
+		for _, m := range memberships {
+			if m.Role == entity.OrgRoleOwner {
+				owners++
+			}
+			if uint64(m.UserID) == userID {
+				target = m
+			}
+		}
+		if target == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if target.Role == entity.OrgRoleOwner && owners == 1 {
+			return ErrLastOwner
+		}
+
+		return tx.Delete(target).Error
+	})
+}
+
+func (r *OrganizationRepository) GetMembership(orgID, userID uint64) (*entity.Membership, error) {
+	var membership entity.Membership
+	if err := r.db.Preload("User").
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		First(&membership).Error; err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *OrganizationRepository) ListMembers(orgID uint64) ([]*entity.Membership, error) {
+	var memberships []*entity.Membership
+	if err := r.db.Preload("User").
+		Where("organization_id = ?", orgID).
+		Order("created_at").
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// ListByUserID returns the user's memberships with their organizations.
+func (r *OrganizationRepository) ListByUserID(userID uint64) ([]*entity.Membership, error) {
+	var memberships []*entity.Membership
+	if err := r.db.Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewPreferenceRepository(db *gorm.DB) *PreferenceRepository {
+	return &PreferenceRepository{db: db}
+}
+
+func (r *PreferenceRepository) ListByUserID(userID uint64) ([]*entity.Preference, error) {
+	var prefs []*entity.Preference
+	if err := r.db.Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// Update stores the given values and removes the keys in reset, which fall
+// back to their defaults.
+func (r *PreferenceRepository) Update(userID uint64, values map[string]string, reset []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(reset) > 0 {
+			if err := tx.Where("user_id = ? AND key IN ?", userID, reset).
+				Delete(&entity.Preference{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(values) == 0 {
+			return nil
+		}
+
+		prefs := make([]*entity.Preference, 0, len(values))
+		for key, value := range values {
+			prefs = append(prefs, &entity.Preference{UserID: uint(userID), Key: key, Value: value})
+		}
+		return tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).Create(&prefs).Error
+	})
+}
@@ -1,8 +1,13 @@
 package repository
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/gauss2302/testcommm/user/internal/domain/entity"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -14,7 +19,12 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 }
 
 func (r *UserRepository) Create(user *entity.User) error {
-	return r.db.Create(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, entity.EventUserCreated, user)
+	})
 }
 
 func (r *UserRepository) GetByEmail(email string) (*entity.User, error) {
@@ -32,3 +42,323 @@ func (r *UserRepository) GetByID(id uint64) (*entity.User, error) {
 	}
 	return &user, nil
 }
+
+func (r *UserRepository) GetByIDs(ids []uint64) ([]*entity.User, error) {
+	var users []*entity.User
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Delete soft-deletes the user, hiding it from every other query until it is
+// purged.
+func (r *UserRepository) Delete(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user entity.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, entity.EventUserDeleted, &user)
+	})
+}
+
+// PurgeDeleted permanently removes users soft-deleted before the given time
+// together with the records that reference them, and erases their email
+// addresses from invitations and from the payloads of their outbox events.
+func (r *UserRepository) PurgeDeleted(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var users []*entity.User
+		if err := tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(users))
+		emails := make([]string, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+			emails[i] = user.Email
+			if err := recordEvent(tx, entity.EventUserPurged, user); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("user_id IN ?", ids).Delete(&entity.EmailChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&entity.StatusChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.Invitation{}).
+			Where("accepted_by IN ? OR email IN ?", ids, emails).
+			Update("email", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.UserEvent{}).
+			Where("user_id IN ?", ids).
+			Update("payload", gorm.Expr("payload - 'email'")).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Delete(&entity.User{}, ids)
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// UserFilter selects users for the admin directory. Zero values disable the
+// corresponding condition.
+type UserFilter struct {
+	EmailPrefix   string
+	Role          string
+	Status        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// SortBy must be one of the columns in UserSortColumns.
+	SortBy     string
+	Descending bool
+	Limit      int
+	// After continues the listing after the row with this sort value and ID.
+	After *UserCursor
+}
+
+type UserCursor struct {
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// UserSortColumns whitelists the columns the directory can be sorted by.
+var UserSortColumns = map[string]bool{
+	"id":         true,
+	"email":      true,
+	"created_at": true,
+}
+
+func (r *UserRepository) List(filter UserFilter) ([]*entity.User, error) {
+	query := r.db.Model(&entity.User{})
+
+	if filter.EmailPrefix != "" {
+		query = query.Where("email ILIKE ?", escapeLike(filter.EmailPrefix)+"%")
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+
+	sortBy := filter.SortBy
+	if !UserSortColumns[sortBy] {
+		sortBy = "id"
+	}
+	direction, cmp := "ASC", ">"
+	if filter.Descending {
+		direction, cmp = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		if sortBy == "id" {
+			query = query.Where("id "+cmp+" ?", filter.After.ID)
+		} else {
+			value, err := cursorValue(sortBy, filter.After.Value)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("("+sortBy+", id) "+cmp+" (?, ?)", value, filter.After.ID)
+		}
+	}
+
+	if sortBy != "id" {
+		query = query.Order(sortBy + " " + direction)
+	}
+	query = query.Order("id " + direction)
+
+	var users []*entity.User
+	if err := query.Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// CursorFor returns the cursor that continues a listing sorted by sortBy
+// after user.
+func CursorFor(user *entity.User, sortBy string) UserCursor {
+	cursor := UserCursor{ID: user.ID}
+	switch sortBy {
+	case "email":
+		cursor.Value = user.Email
+	case "created_at":
+		cursor.Value = user.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+func cursorValue(sortBy, value string) (interface{}, error) {
+	if sortBy == "created_at" {
+		return time.Parse(time.RFC3339Nano, value)
+	}
+	return value, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// PromoteAdmins grants the admin role to existing accounts with the given
+// emails.
+func (r *UserRepository) PromoteAdmins(emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	return r.db.Model(&entity.User{}).
+		Where("LOWER(email) IN ?", lowerAll(emails)).
+		Update("role", entity.RoleAdmin).Error
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
+
+// SetStatus moves the user to a new status and records the transition in the
+// audit log.
+func (r *UserRepository) SetStatus(id uint64, status, reason string, actorID uint64) (*entity.User, error) {
+	var user entity.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
+			return err
+		}
+		if err := changeStatus(tx, &user, status, reason, actorID); err != nil {
+			return err
+		}
+		return recordEvent(tx, entity.EventUserStatusChanged, &user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// changeStatus moves a locked user to a new status within tx and records the
+// transition in the audit log.
+func changeStatus(tx *gorm.DB, user *entity.User, status, reason string, actorID uint64) error {
+	change := &entity.StatusChange{
+		UserID:     user.ID,
+		FromStatus: user.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		ActorID:    uint(actorID),
+	}
+	if err := tx.Create(change).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"status":            status,
+		"status_reason":     reason,
+		"status_changed_at": now,
+	}).Error; err != nil {
+		return err
+	}
+
+	user.Status, user.StatusReason, user.StatusChangedAt = status, reason, &now
+	return nil
+}
+
+func (r *UserRepository) ListStatusChanges(userID uint) ([]*entity.StatusChange, error) {
+	var changes []*entity.StatusChange
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+type UpsertResult int
+
+const (
+	UpsertUnchanged UpsertResult = iota
+	UpsertCreated
+	UpsertUpdated
+)
+
+// ImportStatusReason is recorded in the audit log for status changes made by
+// imports.
+const ImportStatusReason = "changed by import"
+
+// UpsertByEmail creates the user, or updates the password, role and status of
+// the account with the same email. The password hash to store comes from
+// password, which is given the locked existing account, or nil for a new one,
+// so it can keep a hash that already matches. Changes are recorded in the
+// outbox like any other write, and status changes in the audit log too. A new
+// account keeps the user's CreatedAt when it is set.
+func (r *UserRepository) UpsertByEmail(user *entity.User, password func(existing *entity.User) (string, error)) (UpsertResult, error) {
+	result := UpsertUnchanged
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing entity.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("email = ?", user.Email).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if user.Password, err = password(nil); err != nil {
+				return err
+			}
+			if err := tx.Create(user).Error; err != nil {
+				return err
+			}
+			result = UpsertCreated
+			return recordEvent(tx, entity.EventUserCreated, user)
+		}
+		if err != nil {
+			return err
+		}
+
+		if user.Password, err = password(&existing); err != nil {
+			return err
+		}
+
+		if existing.Password == user.Password && existing.Role == user.Role && existing.Status == user.Status {
+			*user = existing
+			return nil
+		}
+
+		eventType := entity.EventUserUpdated
+		if existing.Status != user.Status {
+			eventType = entity.EventUserStatusChanged
+			if err := changeStatus(tx, &existing, user.Status, ImportStatusReason, 0); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"password": user.Password,
+			"role":     user.Role,
+		}).Error; err != nil {
+			return err
+		}
+
+		existing.Password, existing.Role = user.Password, user.Role
+		*user = existing
+		result = UpsertUpdated
+		return recordEvent(tx, eventType, user)
+	})
+	return result, err
+}
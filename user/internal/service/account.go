@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// DeleteUser soft-deletes the account. It is purged for good once the
+// deletion grace period has passed.
+func (s *UserService) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if err := s.userRepo.Delete(req.Id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, err
+	}
+
+	return &pb.DeleteUserResponse{
+		PurgeAt: time.Now().Add(s.cfg.Deletion.GracePeriod).Format(time.RFC3339),
+	}, nil
+}
+
+type userExport struct {
+	ID            uint                 `json:"id"`
+	Email         string               `json:"email"`
+	Role          string               `json:"role"`
+	Status        string               `json:"status"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	EmailChanges  []emailChangeExport  `json:"email_changes"`
+	StatusChanges []statusChangeExport `json:"status_changes"`
+	Memberships   []membershipExport   `json:"memberships"`
+	Preferences   map[string]string    `json:"preferences"`
+}
+
+type membershipExport struct {
+	OrganizationID   uint      `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Role             string    `json:"role"`
+	JoinedAt         time.Time `json:"joined_at"`
+}
+
+type statusChangeExport struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+type emailChangeExport struct {
+	OldEmail    string     `json:"old_email"`
+	NewEmail    string     `json:"new_email"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	RevertedAt  *time.Time `json:"reverted_at,omitempty"`
+}
+
+// ExportUserData returns everything the user service stores about the user,
+// except credentials and token hashes.
+func (s *UserService) ExportUserData(ctx context.Context, req *pb.ExportUserDataRequest) (*pb.ExportUserDataResponse, error) {
+	user, err := s.userRepo.GetByID(req.Id)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	changes, err := s.emailChangeRepo.ListByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	statusChanges, err := s.userRepo.ListStatusChanges(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.orgRepo.ListByUserID(uint64(user.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	prefs, err := s.preferences(uint64(user.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	export := userExport{
+		ID:            user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		Status:        user.Status,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		EmailChanges:  make([]emailChangeExport, 0, len(changes)),
+		StatusChanges: make([]statusChangeExport, 0, len(statusChanges)),
+		Memberships:   make([]membershipExport, 0, len(memberships)),
+		Preferences:   prefs.Values,
+	}
+	for _, c := range changes {
+		export.EmailChanges = append(export.EmailChanges, emailChangeExport{
+			OldEmail:    c.OldEmail,
+			NewEmail:    c.NewEmail,
+			RequestedAt: c.CreatedAt,
+			ConfirmedAt: c.ConfirmedAt,
+			RevertedAt:  c.RevertedAt,
+		})
+	}
+
+	for _, c := range statusChanges {
+		export.StatusChanges = append(export.StatusChanges, statusChangeExport{
+			FromStatus: c.FromStatus,
+			ToStatus:   c.ToStatus,
+			Reason:     c.Reason,
+			ChangedAt:  c.CreatedAt,
+		})
+	}
+
+	for _, m := range memberships {
+		export.Memberships = append(export.Memberships, membershipExport{
+			OrganizationID:   m.OrganizationID,
+			OrganizationName: m.Organization.Name,
+			Role:             m.Role,
+			JoinedAt:         m.CreatedAt,
+		})
+	}
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ExportUserDataResponse{Data: data}, nil
+}
+
+// RunPurger hard-deletes accounts whose grace period has expired and prunes
+// old outbox events, once per purge interval, until ctx is cancelled.
+func (s *UserService) RunPurger(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Deletion.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.userRepo.PurgeDeleted(time.Now().Add(-s.cfg.Deletion.GracePeriod))
+		if err != nil {
+			log.Printf("Failed to purge deleted users: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted users", purged)
+		}
+
+		if _, err := s.eventRepo.DeleteBefore(time.Now().Add(-s.cfg.Events.Retention)); err != nil {
+			log.Printf("Failed to prune user events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
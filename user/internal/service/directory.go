@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"github.com/gauss2302/testcommm/user/internal/repository"
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// directoryCursor is the content of an opaque directory cursor. It records
+// the sort order it was issued for so it cannot be replayed against another
+// one.
+type directoryCursor struct {
+	Value      string `json:"v,omitempty"`
+	ID         uint   `json:"id"`
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+}
+
+// ListUsers pages through the user directory. Results are ordered by the
+// requested column with the ID as a tie-breaker, so cursors stay stable while
+// rows are inserted.
+func (s *UserService) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	filter := repository.UserFilter{
+		EmailPrefix: req.EmailPrefix,
+		Role:        req.Role,
+		Status:      req.Status,
+		SortBy:      req.SortBy,
+		Descending:  req.Descending,
+		Limit:       int(req.PageSize),
+	}
+	if filter.SortBy == "" {
+		filter.SortBy = "id"
+	}
+	if !repository.UserSortColumns[filter.SortBy] {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported sort field %q", req.SortBy)
+	}
+	if filter.Role != "" && filter.Role != entity.RoleUser && filter.Role != entity.RoleAdmin {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role %q", req.Role)
+	}
+	if filter.Status != "" && !entity.ValidStatus(filter.Status) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown status %q", req.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	var err error
+	if filter.CreatedAfter, err = parseTime(req.CreatedAfter); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid created_after")
+	}
+	if filter.CreatedBefore, err = parseTime(req.CreatedBefore); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid created_before")
+	}
+	if req.Cursor != "" {
+		cursor, err := s.decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+		if cursor.SortBy != filter.SortBy || cursor.Descending != filter.Descending {
+			return nil, status.Error(codes.InvalidArgument, "cursor was issued for another sort order")
+		}
+		filter.After = &repository.UserCursor{Value: cursor.Value, ID: cursor.ID}
+	}
+
+	// Fetch one extra row to learn whether another page exists
+	limit := filter.Limit
+	filter.Limit++
+	users, err := s.userRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListUsersResponse{}
+	if len(users) > limit {
+		users = users[:limit]
+		after := repository.CursorFor(users[len(users)-1], filter.SortBy)
+		resp.NextCursor = s.encodeCursor(directoryCursor{
+			Value:      after.Value,
+			ID:         after.ID,
+			SortBy:     filter.SortBy,
+			Descending: filter.Descending,
+		})
+	}
+	for _, user := range users {
+		resp.Users = append(resp.Users, toProto(user))
+	}
+
+	return resp, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// encodeCursor serializes the cursor and appends an HMAC so clients cannot
+// forge or tamper with it.
+func (s *UserService) encodeCursor(cursor directoryCursor) string {
+	data, _ := json.Marshal(cursor)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.signCursor(payload))
+}
+
+func (s *UserService) decodeCursor(token string) (*directoryCursor, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, s.signCursor(payload)) {
+		return nil, errInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor directoryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func (s *UserService) signCursor(payload string) []byte {
+	mac := hmac.New(sha256.New, s.cfg.CursorSecret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
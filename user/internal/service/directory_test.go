@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/gauss2302/testcommm/user/config"
+	pb "github.com/gauss2302/testcommm/user/proto"
+)
+
+func TestDirectoryCursorRoundTrip(t *testing.T) {
+	s := &UserService{cfg: &config.Config{CursorSecret: []byte("secret")}}
+
+	for _, want := range []directoryCursor{
+		{ID: 1, SortBy: "id"},
+		{Value: "a@example.com", ID: 7, SortBy: "email", Descending: true},
+		{Value: "2024-01-02T03:04:05.123456Z", ID: 9, SortBy: "created_at"},
+	} {
+		got, err := s.decodeCursor(s.encodeCursor(want))
+		if err != nil {
+			t.Errorf("decodeCursor(encodeCursor(%+v)): %v", want, err)
+			continue
+		}
+		if *got != want {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", want, *got)
+		}
+	}
+}
+
+func TestListUsersRejectsCursor(t *testing.T) {
+	s := &UserService{cfg: &config.Config{CursorSecret: []byte("secret")}}
+	other := &UserService{cfg: &config.Config{CursorSecret: []byte("other secret")}}
+
+	byEmail := s.encodeCursor(directoryCursor{Value: "a@example.com", ID: 7, SortBy: "email"})
+	payload := byEmail[:len(byEmail)-1]
+
+	tests := []struct {
+		name string
+		req  *pb.ListUsersRequest
+	}{
+		{"other sort", &pb.ListUsersRequest{Cursor: byEmail, SortBy: "created_at"}},
+		{"default sort", &pb.ListUsersRequest{Cursor: byEmail}},
+		{"other direction", &pb.ListUsersRequest{Cursor: byEmail, SortBy: "email", Descending: true}},
+		{"tampered", &pb.ListUsersRequest{Cursor: payload, SortBy: "email"}},
+		{"unsigned", &pb.ListUsersRequest{Cursor: "eyJ2IjoiYSIsImlkIjo3LCJzIjoiZW1haWwifQ", SortBy: "email"}},
+		{"other secret", &pb.ListUsersRequest{Cursor: other.encodeCursor(directoryCursor{ID: 7, SortBy: "email"}), SortBy: "email"}},
+	}
+
+	for _, tt := range tests {
+		_, err := s.ListUsers(context.Background(), tt.req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: ListUsers error = %v, want %s", tt.name, err, codes.InvalidArgument)
+		}
+	}
+}
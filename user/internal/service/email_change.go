@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"github.com/gauss2302/testcommm/user/internal/notifier"
+	"github.com/gauss2302/testcommm/user/internal/repository"
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func (s *UserService) RequestEmailChange(ctx context.Context, req *pb.RequestEmailChangeRequest) (*pb.RequestEmailChangeResponse, error) {
+	// Store the bare address, not a display name form like "Bob <bob@x.io>"
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.NewEmail))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid email address")
+	}
+	newEmail := addr.Address
+
+	user, err := s.userRepo.GetByID(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if strings.EqualFold(user.Email, newEmail) {
+		return nil, status.Error(codes.InvalidArgument, "new email matches the current one")
+	}
+	if _, err := s.userRepo.GetByEmail(newEmail); err == nil {
+		return nil, status.Error(codes.AlreadyExists, repository.ErrEmailTaken.Error())
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	change := &entity.EmailChange{
+		UserID:    user.ID,
+		OldEmail:  user.Email,
+		NewEmail:  newEmail,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.cfg.EmailChange.ConfirmTTL),
+	}
+	if err := s.emailChangeRepo.Create(change); err != nil {
+		return nil, err
+	}
+
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Follow this link to start using %s for your account:\n\n%s\n\nThe link expires at %s.",
+			newEmail, s.link("/email/confirm", token), change.ExpiresAt.Format(time.RFC1123)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RequestEmailChangeResponse{
+		ExpiresAt: change.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *UserService) ConfirmEmailChange(ctx context.Context, req *pb.ConfirmEmailChangeRequest) (*pb.User, error) {
+	change, err := s.emailChangeRepo.GetPendingByTokenHash(hashToken(req.Token))
+	if err != nil {
+		return nil, emailChangeError(err)
+	}
+
+	revertToken, revertHash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.emailChangeRepo.Confirm(change, revertHash, time.Now().Add(s.cfg.EmailChange.RevertTTL)); err != nil {
+		return nil, emailChangeError(err)
+	}
+
+	// The swap is already committed, so a failed notification must not fail
+	// the request.
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      change.OldEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("The email address on your account was changed to %s.\n\nIf you did not make this change, follow this link to restore %s:\n\n%s",
+			change.NewEmail, change.OldEmail, s.link("/email/revert", revertToken)),
+	})
+	if err != nil {
+		log.Printf("Failed to notify %s about email change: %v", change.OldEmail, err)
+	}
+
+	return s.GetUserByID(ctx, &pb.GetUserByIDRequest{Id: uint64(change.UserID)})
+}
+
+func (s *UserService) RevertEmailChange(ctx context.Context, req *pb.RevertEmailChangeRequest) (*pb.User, error) {
+	change, err := s.emailChangeRepo.GetRevertableByTokenHash(hashToken(req.Token))
+	if err != nil {
+		return nil, emailChangeError(err)
+	}
+
+	if err := s.emailChangeRepo.Revert(change); err != nil {
+		return nil, emailChangeError(err)
+	}
+
+	return s.GetUserByID(ctx, &pb.GetUserByIDRequest{Id: uint64(change.UserID)})
+}
+
+func (s *UserService) link(path, token string) string {
+	return strings.TrimRight(s.cfg.Mail.PublicURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func emailChangeError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrChangeNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, repository.ErrChangeNotFound.Error())
+	case errors.Is(err, repository.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrEmailChanged):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/user/internal/repository"
+)
+
+func TestEmailChangeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{repository.ErrChangeNotFound, codes.NotFound},
+		{gorm.ErrRecordNotFound, codes.NotFound},
+		{repository.ErrEmailTaken, codes.AlreadyExists},
+		{repository.ErrEmailChanged, codes.FailedPrecondition},
+		{fmt.Errorf("confirm: %w", repository.ErrEmailChanged), codes.FailedPrecondition},
+		{errors.New("connection reset"), codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := status.Code(emailChangeError(tt.err)); got != tt.want {
+			t.Errorf("emailChangeError(%v) code = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
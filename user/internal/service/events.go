@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const eventBatchSize = 100
+
+// WatchUserEvents streams outbox events after the requested cursor, polling
+// for new ones until the client disconnects.
+func (s *UserService) WatchUserEvents(req *pb.WatchUserEventsRequest, stream grpc.ServerStreamingServer[pb.UserEvent]) error {
+	var cursor uint64
+	switch {
+	case req.Cursor != "":
+		c, err := strconv.ParseUint(req.Cursor, 10, 64)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "invalid cursor")
+		}
+		cursor = c
+
+		// Events after the cursor may have been pruned already, which the
+		// consumer has to know rather than silently miss them
+		oldest, err := s.eventRepo.OldestID()
+		if err != nil {
+			return err
+		}
+		if oldest > cursor+1 {
+			log.Printf("Consumer cursor %d is behind the retained events, which start at %d", cursor, oldest)
+			return status.Errorf(codes.OutOfRange, "events after cursor %d were pruned; the oldest retained event is %d", cursor, oldest)
+		}
+	case req.StartAtLatest:
+		latest, err := s.eventRepo.LatestID()
+		if err != nil {
+			return err
+		}
+		cursor = latest
+	}
+
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.cfg.Events.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		events, err := s.eventRepo.ListAfter(cursor, req.Types, eventBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			msg, err := eventToProto(event)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+			cursor = event.ID
+		}
+
+		// Drain backlogs without waiting for the next tick
+		if len(events) == eventBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func eventToProto(event *entity.UserEvent) (*pb.UserEvent, error) {
+	var snapshot entity.UserSnapshot
+	if err := json.Unmarshal([]byte(event.Payload), &snapshot); err != nil {
+		return nil, err
+	}
+
+	user := &pb.User{
+		Id:           uint64(snapshot.ID),
+		Email:        snapshot.Email,
+		Role:         snapshot.Role,
+		CreatedAt:    snapshot.CreatedAt.Format(time.RFC3339),
+		Status:       snapshot.Status,
+		StatusReason: snapshot.StatusReason,
+	}
+	if event.Type == entity.EventUserPurged {
+		user = &pb.User{Id: uint64(snapshot.ID)}
+	}
+
+	return &pb.UserEvent{
+		Cursor:     strconv.FormatUint(event.ID, 10),
+		Type:       event.Type,
+		UserId:     uint64(event.UserID),
+		User:       user,
+		OccurredAt: event.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"github.com/gauss2302/testcommm/user/internal/notifier"
+	"github.com/gauss2302/testcommm/user/internal/repository"
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateInvitation invites an email address to the organization and sends
+// the invitation link through the notifier.
+func (s *UserService) CreateInvitation(ctx context.Context, req *pb.CreateInvitationRequest) (*pb.Invitation, error) {
+	email := strings.TrimSpace(req.Email)
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid email address")
+	}
+	if entity.OrgRoleRank(req.Role) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role %q", req.Role)
+	}
+	if err := s.requireOrgRole(req.OrganizationId, req.ActorId, entity.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.requireGrantable(req.OrganizationId, req.ActorId, req.Role); err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(req.OrganizationId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "organization not found")
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &entity.Invitation{
+		OrganizationID: org.ID,
+		Email:          email,
+		Role:           req.Role,
+		TokenHash:      hash,
+		InvitedBy:      uint(req.ActorId),
+		ExpiresAt:      time.Now().Add(s.cfg.Invitation.TTL),
+		Organization:   *org,
+	}
+	if err := s.invitationRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      email,
+		Subject: fmt.Sprintf("You have been invited to join %s", org.Name),
+		Body: fmt.Sprintf("You have been invited to join %s as %s.\n\nFollow this link to accept:\n\n%s\n\nThe invitation expires at %s.",
+			org.Name, req.Role, s.link("/invitations", token), invitation.ExpiresAt.Format(time.RFC1123)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitationToProto(invitation), nil
+}
+
+func (s *UserService) ListInvitations(ctx context.Context, req *pb.ListInvitationsRequest) (*pb.ListInvitationsResponse, error) {
+	if err := s.requireOrgRole(req.OrganizationId, req.ActorId, entity.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.invitationRepo.ListPending(req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListInvitationsResponse{}
+	for _, invitation := range invitations {
+		resp.Invitations = append(resp.Invitations, invitationToProto(invitation))
+	}
+	return resp, nil
+}
+
+func (s *UserService) RevokeInvitation(ctx context.Context, req *pb.RevokeInvitationRequest) (*pb.RevokeInvitationResponse, error) {
+	if err := s.requireOrgRole(req.OrganizationId, req.ActorId, entity.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	if err := s.invitationRepo.Revoke(req.OrganizationId, req.InvitationId); err != nil {
+		return nil, invitationError(err)
+	}
+	return &pb.RevokeInvitationResponse{}, nil
+}
+
+// GetInvitation lets the invitee preview a pending invitation before signing
+// up or accepting it.
+func (s *UserService) GetInvitation(ctx context.Context, req *pb.GetInvitationRequest) (*pb.Invitation, error) {
+	invitation, err := s.invitationRepo.GetPendingByTokenHash(hashToken(req.Token))
+	if err != nil {
+		return nil, invitationError(err)
+	}
+	return invitationToProto(invitation), nil
+}
+
+func (s *UserService) AcceptInvitation(ctx context.Context, req *pb.AcceptInvitationRequest) (*pb.Member, error) {
+	invitation, err := s.invitationRepo.GetPendingByTokenHash(hashToken(req.Token))
+	if err != nil {
+		return nil, invitationError(err)
+	}
+
+	user, err := s.userRepo.GetByID(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if !invitation.SentTo(user.Email) {
+		return nil, status.Error(codes.PermissionDenied, "invitation was sent to a different email address")
+	}
+
+	membership, err := s.invitationRepo.Accept(invitation, user.ID)
+	if err != nil {
+		return nil, invitationError(err)
+	}
+	membership.User = *user
+
+	return memberToProto(membership), nil
+}
+
+func invitationError(err error) error {
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return orgError(err)
+}
+
+func invitationToProto(invitation *entity.Invitation) *pb.Invitation {
+	return &pb.Invitation{
+		Id:               uint64(invitation.ID),
+		OrganizationId:   uint64(invitation.OrganizationID),
+		OrganizationName: invitation.Organization.Name,
+		Email:            invitation.Email,
+		Role:             invitation.Role,
+		InvitedBy:        uint64(invitation.InvitedBy),
+		ExpiresAt:        invitation.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:        invitation.CreatedAt.Format(time.RFC3339),
+	}
+}
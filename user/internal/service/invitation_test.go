@@ -0,0 +1,31 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+
+	"github.com/gauss2302/testcommm/user/internal/repository"
+)
+
+func TestInvitationError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{repository.ErrInvitationNotFound, codes.NotFound},
+		{repository.ErrAlreadyMember, codes.AlreadyExists},
+		{repository.ErrLastOwner, codes.FailedPrecondition},
+		{gorm.ErrRecordNotFound, codes.NotFound},
+		{errors.New("connection reset"), codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := status.Code(invitationError(tt.err)); got != tt.want {
+			t.Errorf("invitationError(%v) code = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
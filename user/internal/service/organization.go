@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"github.com/gauss2302/testcommm/user/internal/repository"
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func (s *UserService) CreateOrganization(ctx context.Context, req *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if _, err := s.userRepo.GetByID(req.OwnerId); err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	org := &entity.Organization{Name: name}
+	if err := s.orgRepo.Create(org, uint(req.OwnerId)); err != nil {
+		return nil, err
+	}
+
+	return orgToProto(org), nil
+}
+
+func (s *UserService) ListUserOrganizations(ctx context.Context, req *pb.ListUserOrganizationsRequest) (*pb.ListUserOrganizationsResponse, error) {
+	memberships, err := s.orgRepo.ListByUserID(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListUserOrganizationsResponse{}
+	for _, m := range memberships {
+		resp.Organizations = append(resp.Organizations, &pb.UserOrganization{
+			Organization: orgToProto(&m.Organization),
+			Role:         m.Role,
+		})
+	}
+	return resp, nil
+}
+
+// AddMember adds an existing user to the organization. Actors may only grant
+// roles up to their own.
+func (s *UserService) AddMember(ctx context.Context, req *pb.AddMemberRequest) (*pb.Member, error) {
+	if entity.OrgRoleRank(req.Role) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown role %q", req.Role)
+	}
+	if err := s.requireOrgRole(req.OrganizationId, req.ActorId, entity.OrgRoleAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.requireGrantable(req.OrganizationId, req.ActorId, req.Role); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return s.addMember(req.OrganizationId, user, req.Role)
+}
+
+func (s *UserService) addMember(orgID uint64, user *entity.User, role string) (*pb.Member, error) {
+	membership := &entity.Membership{
+		OrganizationID: uint(orgID),
+		UserID:         user.ID,
+		Role:           role,
+	}
+	if err := s.orgRepo.AddMember(membership); err != nil {
+		return nil, orgError(err)
+	}
+	membership.User = *user
+
+	return memberToProto(membership), nil
+}
+
+// RemoveMember removes a member. Admins and owners may remove members of
+// equal or lower rank, and every member may leave on their own.
+func (s *UserService) RemoveMember(ctx context.Context, req *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+	if req.ActorId != req.UserId {
+		if err := s.requireOrgRole(req.OrganizationId, req.ActorId, entity.OrgRoleAdmin); err != nil {
+			return nil, err
+		}
+		target, err := s.orgRepo.GetMembership(req.OrganizationId, req.UserId)
+		if err != nil {
+			return nil, orgError(err)
+		}
+		if err := s.requireGrantable(req.OrganizationId, req.ActorId, target.Role); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.orgRepo.RemoveMember(req.OrganizationId, req.UserId); err != nil {
+		return nil, orgError(err)
+	}
+	return &pb.RemoveMemberResponse{}, nil
+}
+
+func (s *UserService) ListMembers(ctx context.Context, req *pb.ListMembersRequest) (*pb.ListMembersResponse, error) {
+	if err := s.requireOrgRole(req.OrganizationId, req.ActorId, entity.OrgRoleMember); err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.orgRepo.ListMembers(req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListMembersResponse{}
+	for _, m := range memberships {
+		resp.Members = append(resp.Members, memberToProto(m))
+	}
+	return resp, nil
+}
+
+// GetMembership is used by other services to authorize access to
+// organization resources.
+func (s *UserService) GetMembership(ctx context.Context, req *pb.GetMembershipRequest) (*pb.Member, error) {
+	membership, err := s.orgRepo.GetMembership(req.OrganizationId, req.UserId)
+	if err != nil {
+		return nil, orgError(err)
+	}
+	return memberToProto(membership), nil
+}
+
+func (s *UserService) requireOrgRole(orgID, userID uint64, role string) error {
+	membership, err := s.orgRepo.GetMembership(orgID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.PermissionDenied, "not a member of the organization")
+	}
+	if err != nil {
+		return err
+	}
+	if !membership.HasRole(role) {
+		return status.Errorf(codes.PermissionDenied, "%s role required", role)
+	}
+	return nil
+}
+
+// requireGrantable checks that the actor's role is at least role.
+func (s *UserService) requireGrantable(orgID, actorID uint64, role string) error {
+	if err := s.requireOrgRole(orgID, actorID, role); err != nil {
+		return status.Errorf(codes.PermissionDenied, "cannot manage members with the %s role", role)
+	}
+	return nil
+}
+
+func orgError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return status.Error(codes.NotFound, "membership not found")
+	case errors.Is(err, repository.ErrAlreadyMember):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrLastOwner):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
+
+func orgToProto(org *entity.Organization) *pb.Organization {
+	return &pb.Organization{
+		Id:        uint64(org.ID),
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func memberToProto(m *entity.Membership) *pb.Member {
+	return &pb.Member{
+		OrganizationId: uint64(m.OrganizationID),
+		UserId:         uint64(m.UserID),
+		Email:          m.User.Email,
+		Role:           m.Role,
+		JoinedAt:       m.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type preferenceKind int
+
+const (
+	kindBool preferenceKind = iota
+	kindEnum
+	kindEnumList
+	kindCurrency
+)
+
+type preferenceSchema struct {
+	kind    preferenceKind
+	def     string
+	allowed []string
+}
+
+// preferenceSchemas defines every supported preference. Values travel as
+// strings: booleans as "true"/"false" and lists as comma-separated values.
+var preferenceSchemas = map[string]preferenceSchema{
+	"newsletter_opt_in":     {kind: kindBool, def: "false"},
+	"notification_channels": {kind: kindEnumList, def: "email", allowed: []string{"email", "sms", "push"}},
+	"ui_theme":              {kind: kindEnum, def: "system", allowed: []string{"light", "dark", "system"}},
+	"default_currency":      {kind: kindCurrency, def: "USD"},
+}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// normalize validates value against the schema and returns its canonical form.
+func (p preferenceSchema) normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch p.kind {
+	case kindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("expected true or false")
+		}
+		return strconv.FormatBool(b), nil
+	case kindEnum:
+		if !p.allows(value) {
+			return "", fmt.Errorf("expected one of %s", strings.Join(p.allowed, ", "))
+		}
+		return value, nil
+	case kindEnumList:
+		seen := map[string]bool{}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" || seen[item] {
+				continue
+			}
+			if !p.allows(item) {
+				return "", fmt.Errorf("expected a list of %s", strings.Join(p.allowed, ", "))
+			}
+			seen[item] = true
+			items = append(items, item)
+		}
+		sort.Strings(items)
+		return strings.Join(items, ","), nil
+	case kindCurrency:
+		value = strings.ToUpper(value)
+		if !currencyCode.MatchString(value) {
+			return "", fmt.Errorf("expected an ISO 4217 currency code")
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("unsupported preference")
+}
+
+func (p preferenceSchema) allows(value string) bool {
+	for _, allowed := range p.allowed {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPreferences returns every preference, filling in defaults for those the
+// user has not set.
+func (s *UserService) GetPreferences(ctx context.Context, req *pb.GetPreferencesRequest) (*pb.Preferences, error) {
+	if _, err := s.userRepo.GetByID(req.UserId); err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return s.preferences(req.UserId)
+}
+
+// UpdatePreferences validates and stores the given values. An empty value
+// resets the preference to its default.
+func (s *UserService) UpdatePreferences(ctx context.Context, req *pb.UpdatePreferencesRequest) (*pb.Preferences, error) {
+	if _, err := s.userRepo.GetByID(req.UserId); err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	values := make(map[string]string, len(req.Values))
+	var reset []string
+	for key, value := range req.Values {
+		schema, ok := preferenceSchemas[key]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown preference %q", key)
+		}
+		if strings.TrimSpace(value) == "" {
+			reset = append(reset, key)
+			continue
+		}
+
+		normalized, err := schema.normalize(value)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", key, err)
+		}
+		values[key] = normalized
+	}
+
+	if err := s.prefRepo.Update(req.UserId, values, reset); err != nil {
+		return nil, err
+	}
+
+	return s.preferences(req.UserId)
+}
+
+func (s *UserService) preferences(userID uint64) (*pb.Preferences, error) {
+	stored, err := s.prefRepo.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(preferenceSchemas))
+	for key, schema := range preferenceSchemas {
+		values[key] = schema.def
+	}
+	for _, pref := range stored {
+		// Keys dropped from the schema are ignored
+		if _, ok := preferenceSchemas[pref.Key]; ok {
+			values[pref.Key] = pref.Value
+		}
+	}
+
+	return &pb.Preferences{UserId: userID, Values: values}, nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	pb "github.com/gauss2302/testcommm/user/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// SetUserStatus changes the account status on behalf of an admin. Callers are
+// responsible for revoking the sessions of accounts that are no longer active.
+func (s *UserService) SetUserStatus(ctx context.Context, req *pb.SetUserStatusRequest) (*pb.User, error) {
+	if !entity.ValidStatus(req.Status) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown status %q", req.Status)
+	}
+	if req.Status != entity.StatusActive && req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+	if req.ActorId == req.Id {
+		return nil, status.Error(codes.InvalidArgument, "cannot change own status")
+	}
+
+	user, err := s.userRepo.SetStatus(req.Id, req.Status, req.Reason, req.ActorId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toProto(user), nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/gauss2302/testcommm/user/proto"
+)
+
+func TestSetUserStatusRejects(t *testing.T) {
+	// Every request here fails validation before the repository is used
+	s := &UserService{}
+
+	tests := []struct {
+		name string
+		req  *pb.SetUserStatusRequest
+	}{
+		{"unknown status", &pb.SetUserStatusRequest{Id: 2, ActorId: 1, Status: "deleted", Reason: "spam"}},
+		{"empty status", &pb.SetUserStatusRequest{Id: 2, ActorId: 1, Reason: "spam"}},
+		{"status in another case", &pb.SetUserStatusRequest{Id: 2, ActorId: 1, Status: "Suspended", Reason: "spam"}},
+		{"suspend without reason", &pb.SetUserStatusRequest{Id: 2, ActorId: 1, Status: "suspended"}},
+		{"ban without reason", &pb.SetUserStatusRequest{Id: 2, ActorId: 1, Status: "banned"}},
+		{"pending without reason", &pb.SetUserStatusRequest{Id: 2, ActorId: 1, Status: "pending"}},
+		{"ban own account", &pb.SetUserStatusRequest{Id: 1, ActorId: 1, Status: "banned", Reason: "test"}},
+		{"reactivate own account", &pb.SetUserStatusRequest{Id: 1, ActorId: 1, Status: "active"}},
+	}
+
+	for _, tt := range tests {
+		_, err := s.SetUserStatus(context.Background(), tt.req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: SetUserStatus error = %v, want %s", tt.name, err, codes.InvalidArgument)
+		}
+	}
+}
@@ -3,20 +3,51 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/gauss2302/testcommm/user/config"
 	"github.com/gauss2302/testcommm/user/internal/domain/entity"
+	"github.com/gauss2302/testcommm/user/internal/notifier"
 	"github.com/gauss2302/testcommm/user/internal/repository"
 	pb "github.com/gauss2302/testcommm/user/proto"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserService struct {
 	pb.UnimplementedUserServiceServer
-	userRepo *repository.UserRepository
+	userRepo        *repository.UserRepository
+	emailChangeRepo *repository.EmailChangeRepository
+	orgRepo         *repository.OrganizationRepository
+	invitationRepo  *repository.InvitationRepository
+	eventRepo       *repository.EventRepository
+	prefRepo        *repository.PreferenceRepository
+	notifier        notifier.Notifier
+	cfg             *config.Config
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(
+	userRepo *repository.UserRepository,
+	emailChangeRepo *repository.EmailChangeRepository,
+	orgRepo *repository.OrganizationRepository,
+	invitationRepo *repository.InvitationRepository,
+	eventRepo *repository.EventRepository,
+	prefRepo *repository.PreferenceRepository,
+	notifier notifier.Notifier,
+	cfg *config.Config,
+) *UserService {
+	return &UserService{
+		userRepo:        userRepo,
+		emailChangeRepo: emailChangeRepo,
+		orgRepo:         orgRepo,
+		invitationRepo:  invitationRepo,
+		eventRepo:       eventRepo,
+		prefRepo:        prefRepo,
+		notifier:        notifier,
+		cfg:             cfg,
+	}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
@@ -28,16 +59,18 @@ func (s *UserService) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 	user := &entity.User{
 		Email:    req.Email,
 		Password: string(hashedPassword),
+		Role:     entity.RoleUser,
+		Status:   entity.StatusActive,
+	}
+	if s.isAdminEmail(req.Email) {
+		user.Role = entity.RoleAdmin
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, err
 	}
 
-	return &pb.User{
-		Id:    uint64(user.ID),
-		Email: user.Email,
-	}, nil
+	return toProto(user), nil
 }
 
 func (s *UserService) VerifyUser(ctx context.Context, req *pb.VerifyUserRequest) (*pb.User, error) {
@@ -50,10 +83,12 @@ func (s *UserService) VerifyUser(ctx context.Context, req *pb.VerifyUserRequest)
 		return nil, errors.New("invalid credentials")
 	}
 
-	return &pb.User{
-		Id:    uint64(user.ID),
-		Email: user.Email,
-	}, nil
+	// Checked after the password so the status is only revealed to the owner
+	if user.Status != entity.StatusActive {
+		return nil, status.Errorf(codes.PermissionDenied, "account is %s", user.Status)
+	}
+
+	return toProto(user), nil
 }
 
 func (s *UserService) GetUserByID(ctx context.Context, req *pb.GetUserByIDRequest) (*pb.User, error) {
@@ -62,8 +97,53 @@ func (s *UserService) GetUserByID(ctx context.Context, req *pb.GetUserByIDReques
 		return nil, err
 	}
 
+	return toProto(user), nil
+}
+
+// maxBatchSize bounds the number of IDs accepted by GetUsersByIDs.
+const maxBatchSize = 500
+
+func (s *UserService) GetUsersByIDs(ctx context.Context, req *pb.GetUsersByIDsRequest) (*pb.GetUsersByIDsResponse, error) {
+	if len(req.Ids) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids per request", maxBatchSize)
+	}
+
+	resp := &pb.GetUsersByIDsResponse{Users: make(map[uint64]*pb.UserProfile, len(req.Ids))}
+	if len(req.Ids) == 0 {
+		return resp, nil
+	}
+
+	users, err := s.userRepo.GetByIDs(req.Ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		resp.Users[uint64(user.ID)] = &pb.UserProfile{
+			Id:        uint64(user.ID),
+			Email:     user.Email,
+			CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *UserService) isAdminEmail(email string) bool {
+	for _, admin := range s.cfg.AdminEmails {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func toProto(user *entity.User) *pb.User {
 	return &pb.User{
-		Id:    uint64(user.ID),
-		Email: user.Email,
-	}, nil
+		Id:           uint64(user.ID),
+		Email:        user.Email,
+		Role:         user.Role,
+		CreatedAt:    user.CreatedAt.Format(time.RFC3339),
+		Status:       user.Status,
+		StatusReason: user.StatusReason,
+	}
 }
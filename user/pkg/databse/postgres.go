@@ -17,7 +17,8 @@ func NewPostgresDB(cfg config.DataBaseConfig) (*gorm.DB, error) {
 
 	for i := 0; i < cfg.MaxRetries; i++ {
 		db, err = gorm.Open(postgres.Open(cfg.URL), &gorm.Config{ // убрали :=
-			Logger: logger.Default.LogMode(logger.Error),
+			Logger:         logger.Default.LogMode(logger.Error),
+			TranslateError: true,
 		})
 		if err == nil {
 			break